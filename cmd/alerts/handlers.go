@@ -0,0 +1,184 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package alerts
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/dwui/cmd/docker"
+)
+
+// recentFirings is how many firings the alerts page lists
+const recentFirings = 50
+
+type IndexPageData struct {
+	Rules   []Rule
+	Firings []Firing
+	Form    RuleForm
+	Error   string
+}
+
+// RuleForm holds the values of the new rule form, kept when it has errors
+type RuleForm struct {
+	Name       string
+	Pattern    string
+	Label      string
+	Threshold  string
+	Window     string
+	Cooldown   string
+	WebhookURL string
+}
+
+// Index lists the alert rules and their recent firings
+func Index(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		render(w, templateFS, IndexPageData{Form: defaultForm()})
+	}
+}
+
+// Create stores a new rule from the form and starts evaluating it
+func Create(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+
+		form := RuleForm{
+			Name:       strings.TrimSpace(req.PostForm.Get("name")),
+			Pattern:    req.PostForm.Get("pattern"),
+			Label:      strings.TrimSpace(req.PostForm.Get("label")),
+			Threshold:  req.PostForm.Get("threshold"),
+			Window:     req.PostForm.Get("window"),
+			Cooldown:   req.PostForm.Get("cooldown"),
+			WebhookURL: strings.TrimSpace(req.PostForm.Get("webhook")),
+		}
+		data := IndexPageData{Form: form}
+
+		rule, err := form.Rule()
+		if err == nil {
+			err = rule.Validate()
+		}
+		if err == nil {
+			rule.ID, err = NewRuleID()
+		}
+		if err == nil {
+			err = SaveRule(rule)
+		}
+		if err != nil {
+			data.Error = err.Error()
+			render(w, templateFS, data)
+			return
+		}
+
+		if err := Reload(); err != nil {
+			data.Error = err.Error()
+		}
+		data.Form = defaultForm()
+		render(w, templateFS, data)
+	}
+}
+
+// Update enables, disables or deletes a rule
+func Update(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var ruleID = chi.URLParam(req, "ruleID")
+		var action = chi.URLParam(req, "action")
+		data := IndexPageData{Form: defaultForm()}
+
+		rule, found, err := FindRule(ruleID)
+		if err == nil && !found {
+			http.Error(w, "Unknown alert rule", http.StatusNotFound)
+			return
+		}
+
+		if err == nil {
+			switch action {
+			case "enable", "disable":
+				rule.Enabled = action == "enable"
+				err = SaveRule(rule)
+			case "delete":
+				err = DeleteRule(ruleID)
+			default:
+				http.Error(w, "Unknown alert rule action", http.StatusNotFound)
+				return
+			}
+		}
+		if err == nil {
+			err = Reload()
+		}
+		if err != nil {
+			data.Error = err.Error()
+		}
+
+		render(w, templateFS, data)
+	}
+}
+
+// Rule converts the form into a rule, durations are given in minutes
+func (f RuleForm) Rule() (Rule, error) {
+	threshold, err := strconv.Atoi(f.Threshold)
+	if err != nil {
+		return Rule{}, errors.New("threshold must be a number")
+	}
+	window, err := strconv.ParseFloat(f.Window, 64)
+	if err != nil {
+		return Rule{}, errors.New("window must be a number of minutes")
+	}
+	cooldown, err := strconv.ParseFloat(f.Cooldown, 64)
+	if err != nil {
+		return Rule{}, errors.New("cooldown must be a number of minutes")
+	}
+
+	return Rule{
+		Name:       f.Name,
+		Pattern:    f.Pattern,
+		Label:      f.Label,
+		Threshold:  threshold,
+		Window:     time.Duration(window * float64(time.Minute)),
+		Cooldown:   time.Duration(cooldown * float64(time.Minute)),
+		WebhookURL: f.WebhookURL,
+		Enabled:    true,
+	}, nil
+}
+
+func defaultForm() RuleForm {
+	return RuleForm{Threshold: "5", Window: "5", Cooldown: "15"}
+}
+
+func render(w http.ResponseWriter, templateFS embed.FS, data IndexPageData) {
+	var err error
+	if data.Rules, err = LoadRules(); err != nil && data.Error == "" {
+		data.Error = err.Error()
+	}
+	if data.Firings, err = RecentFirings(recentFirings); err != nil && data.Error == "" {
+		data.Error = err.Error()
+	}
+
+	docker.ReplaceURL(w, "alerts", nil)
+
+	tmpl := template.Must(template.ParseFS(templateFS, "cmd/alerts/index.gohtml"))
+	tmpl.Execute(w, data)
+}
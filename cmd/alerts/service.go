@@ -0,0 +1,240 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/dwui/cmd/database"
+	"github.com/dwui/cmd/docker"
+	"github.com/dwui/cmd/logs"
+)
+
+// firingTTL is how long the firing history is kept
+const firingTTL = 30 * 24 * time.Hour
+
+// maxFiringLines bounds how many matching lines are sent with a firing
+const maxFiringLines = 50
+
+// Rule fires its webhook when Pattern matches at least Threshold lines
+// within Window across the containers carrying Label ("key" or "key=value",
+// empty for every container). After firing it stays quiet for Cooldown so
+// a burst of errors is only reported once.
+type Rule struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Pattern    string        `json:"pattern"`
+	Label      string        `json:"label"`
+	Threshold  int           `json:"threshold"`
+	Window     time.Duration `json:"window"`
+	Cooldown   time.Duration `json:"cooldown"`
+	WebhookURL string        `json:"webhookUrl"`
+	Enabled    bool          `json:"enabled"`
+
+	pattern *regexp.Regexp
+}
+
+// Validate checks the rule and compiles its pattern
+func (r *Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	pattern, err := regexp.Compile(r.Pattern)
+	if err != nil || r.Pattern == "" {
+		return errors.New("pattern must be a valid regular expression")
+	}
+	if r.Threshold < 1 {
+		return errors.New("threshold must be at least 1")
+	}
+	if r.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+	if r.Cooldown < 0 {
+		return errors.New("cooldown cannot be negative")
+	}
+	webhook, err := url.Parse(r.WebhookURL)
+	if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+		return errors.New("webhook must be an http or https URL")
+	}
+
+	r.pattern = pattern
+	return nil
+}
+
+// Matches reports whether a container with the given labels is watched by
+// the rule
+func (r Rule) Matches(labels map[string]string) bool {
+	return docker.HasLabel(labels, r.Label)
+}
+
+// Firing is a single time a rule fired, as sent to the webhook and kept in
+// the history. Status tells how the delivery went and is only set in the
+// history.
+type Firing struct {
+	RuleID   string      `json:"ruleId"`
+	RuleName string      `json:"ruleName"`
+	Pattern  string      `json:"pattern"`
+	Time     time.Time   `json:"time"`
+	Count    int         `json:"count"`
+	Window   string      `json:"window"`
+	Lines    []logs.Line `json:"lines"`
+	Status   string      `json:"status,omitempty"`
+}
+
+// NewRuleID returns a random identifier for a new rule
+func NewRuleID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// SaveRule stores a new or updated rule
+func SaveRule(rule Rule) error {
+	if database.Instance == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	return database.Instance.Update(func(txn *badger.Txn) error {
+		return txn.Set(ruleKey(rule.ID), data)
+	})
+}
+
+// DeleteRule removes a rule, its firing history is left to expire
+func DeleteRule(ruleID string) error {
+	if database.Instance == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	return database.Instance.Update(func(txn *badger.Txn) error {
+		return txn.Delete(ruleKey(ruleID))
+	})
+}
+
+// FindRule returns a single stored rule
+func FindRule(ruleID string) (Rule, bool, error) {
+	rules, err := LoadRules()
+	if err != nil {
+		return Rule{}, false, err
+	}
+	for _, rule := range rules {
+		if rule.ID == ruleID {
+			return rule, true, nil
+		}
+	}
+	return Rule{}, false, nil
+}
+
+// LoadRules returns every stored rule, sorted by name, with its pattern
+// compiled
+func LoadRules() ([]Rule, error) {
+	if database.Instance == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rules := []Rule{}
+	err := database.Instance.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: 100, Prefix: ruleKey("")})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var rule Rule
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &rule)
+			})
+			if err != nil {
+				return err
+			}
+			// Rules were validated when saved, the pattern always compiles
+			rule.pattern, _ = regexp.Compile(rule.Pattern)
+			rules = append(rules, rule)
+		}
+		return nil
+	})
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, err
+}
+
+// RecordFiring adds a firing to the history
+func RecordFiring(firing Firing) error {
+	if database.Instance == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	data, err := json.Marshal(firing)
+	if err != nil {
+		return err
+	}
+	return database.Instance.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(firingKey(firing.Time, firing.RuleID), data).WithTTL(firingTTL))
+	})
+}
+
+// RecentFirings returns the latest firings of every rule, newest first
+func RecentFirings(limit int) ([]Firing, error) {
+	if database.Instance == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	firings := []Firing{}
+	err := database.Instance.View(func(txn *badger.Txn) error {
+		prefix := []byte("alerts:firing:")
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, Reverse: true, Prefix: prefix})
+		defer it.Close()
+
+		for it.Seek(append(prefix, 0xff)); it.Valid() && len(firings) < limit; it.Next() {
+			var firing Firing
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &firing)
+			})
+			if err != nil {
+				return err
+			}
+			firings = append(firings, firing)
+		}
+		return nil
+	})
+	return firings, err
+}
+
+func ruleKey(ruleID string) []byte {
+	return []byte("alerts:rule:" + ruleID)
+}
+
+// firingKey orders the history by time across all rules
+func firingKey(at time.Time, ruleID string) []byte {
+	return []byte(fmt.Sprintf("alerts:firing:%020d:%s", at.UnixNano(), ruleID))
+}
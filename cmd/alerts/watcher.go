@@ -0,0 +1,287 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+
+	"github.com/dwui/cmd/docker"
+	"github.com/dwui/cmd/events"
+	"github.com/dwui/cmd/logs"
+)
+
+// webhookTimeout bounds how long a webhook delivery may take
+const webhookTimeout = 10 * time.Second
+
+// manager holds the loaded rules, their sliding windows and the log tails
+// of the watched containers
+var manager = struct {
+	sync.Mutex
+	rules  []Rule
+	states map[string]*ruleState
+	tails  map[string]*tailHandle
+}{
+	states: map[string]*ruleState{},
+	tails:  map[string]*tailHandle{},
+}
+
+// tailHandle stops the log tail of a watched container
+type tailHandle struct {
+	cancel context.CancelFunc
+}
+
+// ruleState is the sliding window of lines that matched a rule
+type ruleState struct {
+	times     []time.Time
+	lines     []logs.Line
+	lastFired time.Time
+}
+
+// add records a matching line and drops those that left the window
+func (s *ruleState) add(now time.Time, window time.Duration, line logs.Line) {
+	s.times = append(s.times, now)
+	s.lines = append(s.lines, line)
+
+	cutoff := now.Add(-window)
+	drop := 0
+	for drop < len(s.times) && s.times[drop].Before(cutoff) {
+		drop++
+	}
+	s.times = s.times[drop:]
+	s.lines = s.lines[drop:]
+}
+
+// Start loads the rules and evaluates them against the logs of every running
+// container they apply to, picking up containers as they start
+func Start() {
+	if err := Reload(); err != nil {
+		log.Println("Alert rules load error:", err)
+	}
+
+	events.OnConnect(watchRunning)
+	events.OnEvent(func(event events.Event) {
+		if event.Type == "container" && event.Action == "start" {
+			go watchRunning()
+		}
+	})
+}
+
+// Reload reads the rules from the database again, after they were changed
+func Reload() error {
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+
+	manager.Lock()
+	manager.rules = rules
+	states := map[string]*ruleState{}
+	for _, rule := range rules {
+		if state, ok := manager.states[rule.ID]; ok {
+			states[rule.ID] = state
+		}
+	}
+	manager.states = states
+	manager.Unlock()
+
+	go watchRunning()
+	return nil
+}
+
+// watchRunning starts tailing the running containers that an enabled rule
+// applies to and stops the tails no rule needs anymore
+func watchRunning() {
+	cli, err := docker.Client()
+	if err != nil {
+		return
+	}
+	list, err := cli.ContainerList(context.Background(), containertypes.ListOptions{})
+	if err != nil {
+		log.Println("Alert container list error:", err)
+		return
+	}
+
+	manager.Lock()
+	defer manager.Unlock()
+
+	watched := map[string]bool{}
+	for _, container := range list {
+		if !watchedByAny(manager.rules, container.Labels) {
+			continue
+		}
+		watched[container.ID] = true
+		if _, ok := manager.tails[container.ID]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		handle := &tailHandle{cancel: cancel}
+		manager.tails[container.ID] = handle
+		go tail(ctx, handle, container)
+	}
+
+	for containerID, handle := range manager.tails {
+		if !watched[containerID] {
+			handle.cancel()
+			delete(manager.tails, containerID)
+		}
+	}
+}
+
+func watchedByAny(rules []Rule, labels map[string]string) bool {
+	for _, rule := range rules {
+		if rule.Enabled && rule.Matches(labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// tail feeds the new lines of a container to the rules until the container
+// stops or the tail is cancelled
+func tail(ctx context.Context, handle *tailHandle, container containertypes.Summary) {
+	defer func() {
+		manager.Lock()
+		if manager.tails[container.ID] == handle {
+			delete(manager.tails, container.ID)
+		}
+		manager.Unlock()
+		handle.cancel()
+	}()
+
+	cli, err := docker.Client()
+	if err != nil {
+		return
+	}
+	inspect, err := cli.ContainerInspect(ctx, container.ID)
+	if err != nil {
+		return
+	}
+
+	target := logs.Target{
+		ID:   container.ID,
+		Name: strings.TrimPrefix(inspect.Name, "/"),
+		TTY:  inspect.Config != nil && inspect.Config.Tty,
+	}
+
+	// Only lines written from now on are evaluated
+	logs.Stream(ctx, cli, target, logs.StreamOptions{Tail: "0", Timestamps: true}, func(line logs.Line) error {
+		line.Container = target.Name
+		evaluate(container.Labels, line)
+		return ctx.Err()
+	})
+}
+
+// evaluate matches a line against every rule and fires those that reached
+// their threshold outside of their cooldown
+func evaluate(labels map[string]string, line logs.Line) {
+	now := time.Now()
+	type delivery struct {
+		rule   Rule
+		firing Firing
+	}
+	deliveries := []delivery{}
+
+	manager.Lock()
+	for _, rule := range manager.rules {
+		if !rule.Enabled || rule.pattern == nil || !rule.Matches(labels) || !rule.pattern.MatchString(line.Text) {
+			continue
+		}
+
+		state, ok := manager.states[rule.ID]
+		if !ok {
+			state = &ruleState{}
+			manager.states[rule.ID] = state
+		}
+		state.add(now, rule.Window, line)
+
+		if len(state.times) < rule.Threshold || now.Sub(state.lastFired) < rule.Cooldown {
+			continue
+		}
+
+		lines := state.lines[max(0, len(state.lines)-maxFiringLines):]
+		deliveries = append(deliveries, delivery{rule: rule, firing: Firing{
+			RuleID:   rule.ID,
+			RuleName: rule.Name,
+			Pattern:  rule.Pattern,
+			Time:     now,
+			Count:    len(state.times),
+			Window:   rule.Window.String(),
+			Lines:    append([]logs.Line(nil), lines...),
+		}})
+
+		state.lastFired = now
+		state.times = nil
+		state.lines = nil
+	}
+	manager.Unlock()
+
+	for _, d := range deliveries {
+		go deliver(d.rule, d.firing)
+	}
+}
+
+// deliver posts a firing to the rule webhook and records it in the history
+func deliver(rule Rule, firing Firing) {
+	if err := post(rule.WebhookURL, firing); err != nil {
+		log.Printf("Alert %q webhook error: %v", rule.Name, err)
+		firing.Status = "failed: " + err.Error()
+	} else {
+		firing.Status = "delivered"
+	}
+
+	if err := RecordFiring(firing); err != nil {
+		log.Println("Alert history write error:", err)
+	}
+}
+
+func post(webhookURL string, firing Firing) error {
+	payload, err := json.Marshal(firing)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package containers
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+
+	"github.com/dwui/cmd/docker"
+	"github.com/dwui/cmd/events"
+)
+
+// refreshActions are the container events that change what the list shows.
+// The page gets the same list to know which events refresh a row.
+var refreshActions = []string{
+	"create", "start", "restart", "stop", "die", "kill", "oom",
+	"pause", "unpause", "rename", "update", "destroy", "health_status",
+}
+
+// cache holds the summaries of all containers, stopped ones included. It is
+// loaded whenever the events stream connects and then kept fresh by container
+// events, so rendering the list does not hit the daemon.
+var cache = struct {
+	sync.RWMutex
+	containers map[string]containertypes.Summary
+	synced     bool
+	dirty      map[string]struct{}
+	wake       chan struct{}
+}{
+	containers: map[string]containertypes.Summary{},
+	dirty:      map[string]struct{}{},
+	wake:       make(chan struct{}, 1),
+}
+
+// StartCache hooks the cache to the events stream. Changed containers are
+// collected in a dirty set and refreshed in batches by a single worker, so
+// bursts of events never block the stream nor get lost.
+func StartCache() {
+	events.OnConnect(func() {
+		if err := resync(); err != nil {
+			log.Println("Container cache sync error:", err)
+		}
+	})
+
+	events.OnEvent(func(event events.Event) {
+		if event.Type != "container" {
+			return
+		}
+		if !slices.ContainsFunc(refreshActions, func(action string) bool {
+			return strings.HasPrefix(event.Action, action)
+		}) {
+			return
+		}
+
+		cache.Lock()
+		cache.dirty[event.ID] = struct{}{}
+		cache.Unlock()
+
+		select {
+		case cache.wake <- struct{}{}:
+		default:
+		}
+	})
+
+	go func() {
+		for range cache.wake {
+			cache.Lock()
+			containerIDs := make([]string, 0, len(cache.dirty))
+			for containerID := range cache.dirty {
+				containerIDs = append(containerIDs, containerID)
+			}
+			cache.dirty = map[string]struct{}{}
+			cache.Unlock()
+
+			cli, err := docker.Client()
+			if err != nil {
+				continue
+			}
+			if _, err := ListByIDs(context.Background(), cli, containerIDs); err != nil {
+				log.Println("Container cache refresh error:", err)
+			}
+		}
+	}()
+}
+
+// refreshes serializes the daemon reads that update the cache, so that a
+// full listing started before an event can never be stored over the
+// refresh that event triggered
+var refreshes sync.Mutex
+
+// resync replaces the cache with a full listing from the daemon
+func resync() error {
+	refreshes.Lock()
+	defer refreshes.Unlock()
+
+	cli, err := docker.Client()
+	if err != nil {
+		return err
+	}
+
+	list, err := cli.ContainerList(context.Background(), containertypes.ListOptions{All: true})
+	if err != nil {
+		return err
+	}
+
+	containers := make(map[string]containertypes.Summary, len(list))
+	for _, container := range list {
+		containers[container.ID] = container
+	}
+
+	cache.Lock()
+	defer cache.Unlock()
+	cache.containers = containers
+	cache.synced = true
+	return nil
+}
+
+// store records the latest summaries of the given containers. IDs missing
+// from found are containers that no longer exist.
+func store(containerIDs []string, found map[string]containertypes.Summary) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	for _, containerID := range containerIDs {
+		if container, ok := found[containerID]; ok {
+			cache.containers[containerID] = container
+		} else {
+			delete(cache.containers, containerID)
+		}
+	}
+}
+
+// List returns the containers matching filter, newest first like
+// `docker ps`. Filters the cache can answer are served from it, loading it
+// on demand if the events stream has not connected yet; the others are
+// passed to the daemon.
+func List(ctx context.Context, cli *client.Client, filter ListFilter) ([]containertypes.Summary, error) {
+	if !filter.cacheable() {
+		return cli.ContainerList(ctx, filter.Options())
+	}
+
+	cache.RLock()
+	synced := cache.synced
+	cache.RUnlock()
+
+	if !synced {
+		if err := resync(); err != nil {
+			return nil, err
+		}
+	}
+
+	cache.RLock()
+	defer cache.RUnlock()
+
+	list := []containertypes.Summary{}
+	for _, container := range cache.containers {
+		if filter.matchCached(container) {
+			list = append(list, container)
+		}
+	}
+
+	slices.SortFunc(list, func(a, b containertypes.Summary) int {
+		if a.Created != b.Created {
+			return cmp.Compare(b.Created, a.Created)
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return list, nil
+}
+
+// CachedByID returns a single cached container, stopped ones included
+func CachedByID(containerID string) (containertypes.Summary, bool) {
+	cache.RLock()
+	defer cache.RUnlock()
+
+	container, ok := cache.containers[containerID]
+	return container, ok
+}
+
+func idFilter(containerIDs []string) filters.Args {
+	args := filters.NewArgs()
+	for _, containerID := range containerIDs {
+		args.Add("id", containerID)
+	}
+	return args
+}
@@ -20,42 +20,296 @@ import (
 	"context"
 	"embed"
 	"html/template"
+	"log"
 	"net/http"
+	"net/url"
 
 	containertypes "github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/dwui/cmd/docker"
 )
 
 type IndexPageData struct {
-	Containers []containertypes.Summary
+	Containers     []ContainerRow
+	Filter         ListFilter
+	States         []string
+	RefreshActions []string
+}
+
+// ContainerRow is a single entry of the containers list. Error holds the
+// outcome of the last lifecycle action applied to the row, if it failed.
+// SwapOOB marks rows rendered as htmx out-of-band swaps.
+type ContainerRow struct {
+	containertypes.Summary
+	Error   string
+	SwapOOB bool
+}
+
+type BulkPageData struct {
+	Action  string
+	Results []BulkResultRow
+	Rows    []ContainerRow
+	Removed []string
+}
+
+type BulkResultRow struct {
+	ContainerID   string
+	ContainerName string
+	Error         string
+}
+
+// ActionButton describes a lifecycle button rendered in a container row
+type ActionButton struct {
+	ContainerID   string
+	ContainerName string
+	Name          string
+	Label         string
+	Destructive   bool
+}
+
+func NewActionButton(row ContainerRow, name string, label string, destructive bool) ActionButton {
+	return ActionButton{
+		ContainerID:   row.ID,
+		ContainerName: row.DisplayName(),
+		Name:          name,
+		Label:         label,
+		Destructive:   destructive,
+	}
+}
+
+// TabButton describes a button in a container row that opens one of the
+// container views (stats, processes, ...) in the detail pane
+type TabButton struct {
+	ContainerID   string
+	ContainerName string
+	Name          string
+	Label         string
+}
+
+func NewTabButton(row ContainerRow, name string, label string) TabButton {
+	return TabButton{
+		ContainerID:   row.ID,
+		ContainerName: row.DisplayName(),
+		Name:          name,
+		Label:         label,
+	}
 }
 
+// DisplayName returns the container's first name, falling back to its short ID
+func (row ContainerRow) DisplayName() string {
+	if len(row.Names) > 0 {
+		return ShortenName(row.Names[0])
+	}
+	return ShortenID(row.ID)
+}
+
+// ProjectLogsURL returns the merged logs view of the compose project the
+// container belongs to, or an empty string for standalone containers
+func (row ContainerRow) ProjectLogsURL() string {
+	project := row.Labels["com.docker.compose.project"]
+	if project == "" {
+		return ""
+	}
+	return "/logs/merged?" + url.Values{"project": {project}}.Encode()
+}
+
+// Index renders the containers list, from the event-driven cache when the
+// filters allow it
 func Index(templateFS embed.FS) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		ctx := context.Background()
-		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		cli, err := docker.Client()
 		if err != nil {
-			panic(err)
+			docker.RenderUnavailable(w, templateFS, err)
+			return
 		}
 
-		defer cli.Close()
-		containers, err := cli.ContainerList(ctx, containertypes.ListOptions{})
+		filter := ParseListFilter(req.URL.Query())
+		containers, err := List(context.Background(), cli, filter)
 		if err != nil {
-			panic(err)
+			docker.RenderUnavailable(w, templateFS, err)
+			return
 		}
 
 		data := IndexPageData{
-			Containers: containers,
+			Filter:         filter,
+			States:         States,
+			RefreshActions: refreshActions,
 		}
-
-		funcMap := template.FuncMap{
-			"shortenID":   ShortenID,
-			"shortenName": ShortenName,
-			"urlQuery":    template.URLQueryEscaper,
+		for _, container := range containers {
+			data.Containers = append(data.Containers, ContainerRow{Summary: container})
 		}
 
-		tmpl := template.Must(template.New("index.gohtml").Funcs(funcMap).ParseFS(templateFS, "cmd/containers/index.gohtml"))
+		docker.ReplaceURL(w, "", filter.Query())
+
+		tmpl := parseIndex(templateFS)
 
 		tmpl.Execute(w, data)
 	}
 }
+
+// Row renders a single row of the list, used to refresh it when Docker
+// reports a change. Containers that are gone or do not match the list
+// filters render nothing.
+func Row(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+
+		cli, err := docker.Client()
+		if err != nil {
+			docker.RenderUnavailable(w, templateFS, err)
+			return
+		}
+
+		// The browser asks for the row right after the event that changed it,
+		// possibly before the cache caught up, so read it from the daemon
+		ctx := context.Background()
+		container, found, err := FindContainer(ctx, cli, containerID)
+		if err != nil {
+			http.Error(w, "Container list error", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			return
+		}
+		matches, err := ParseListFilter(req.URL.Query()).Matches(ctx, cli, container)
+		if err != nil {
+			http.Error(w, "Container list error", http.StatusInternalServerError)
+			return
+		}
+		if !matches {
+			return
+		}
+
+		tmpl := parseIndex(templateFS)
+		tmpl.ExecuteTemplate(w, "row", ContainerRow{Summary: container})
+	}
+}
+
+// Action applies a lifecycle action to a container and renders its refreshed
+// row. Removed containers render nothing so htmx drops the row.
+func Action(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+		var actionName = chi.URLParam(req, "action")
+
+		action, ok := LookupAction(actionName)
+		if !ok {
+			http.Error(w, "Unknown container action", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.Background()
+		cli, err := docker.Client()
+		if err != nil {
+			// The unavailable page would replace the row, keep the row and show
+			// the error in it instead
+			container, found := CachedByID(containerID)
+			if !found {
+				w.Header().Set("HX-Reswap", "none")
+				docker.RenderUnavailable(w, templateFS, err)
+				return
+			}
+			tmpl := parseIndex(templateFS)
+			tmpl.ExecuteTemplate(w, "row", ContainerRow{Summary: container, Error: err.Error()})
+			return
+		}
+
+		row := ContainerRow{}
+		if err := action(ctx, cli, containerID); err != nil {
+			log.Printf("container %s %s error: %v", ShortenID(containerID), actionName, err)
+			row.Error = err.Error()
+		}
+
+		container, found, err := FindContainer(ctx, cli, containerID)
+		if err != nil {
+			http.Error(w, "Container list error", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			return
+		}
+		row.Summary = container
+
+		tmpl := parseIndex(templateFS)
+		tmpl.ExecuteTemplate(w, "row", row)
+	}
+}
+
+// Bulk applies a lifecycle action to every selected container and renders a
+// per-container result table. Affected rows are refreshed out-of-band.
+func Bulk(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var actionName = chi.URLParam(req, "action")
+
+		action, ok := LookupAction(actionName)
+		if !ok {
+			http.Error(w, "Unknown container action", http.StatusNotFound)
+			return
+		}
+
+		if err := req.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+		containerIDs := req.PostForm["id"]
+
+		ctx := context.Background()
+		cli, err := docker.Client()
+		if err != nil {
+			docker.RenderUnavailable(w, templateFS, err)
+			return
+		}
+
+		before, err := ListByIDs(ctx, cli, containerIDs)
+		if err != nil {
+			http.Error(w, "Container list error", http.StatusInternalServerError)
+			return
+		}
+
+		results := RunBulk(ctx, cli, action, containerIDs)
+
+		after, err := ListByIDs(ctx, cli, containerIDs)
+		if err != nil {
+			http.Error(w, "Container list error", http.StatusInternalServerError)
+			return
+		}
+
+		data := BulkPageData{Action: actionName}
+		for _, result := range results {
+			resultRow := BulkResultRow{
+				ContainerID:   result.ContainerID,
+				ContainerName: ShortenID(result.ContainerID),
+			}
+			if container, ok := before[result.ContainerID]; ok && len(container.Names) > 0 {
+				resultRow.ContainerName = ShortenName(container.Names[0])
+			}
+			if result.Error != nil {
+				log.Printf("container %s %s error: %v", ShortenID(result.ContainerID), actionName, result.Error)
+				resultRow.Error = result.Error.Error()
+			}
+			data.Results = append(data.Results, resultRow)
+
+			if container, ok := after[result.ContainerID]; ok {
+				data.Rows = append(data.Rows, ContainerRow{Summary: container, Error: resultRow.Error, SwapOOB: true})
+			} else {
+				data.Removed = append(data.Removed, result.ContainerID)
+			}
+		}
+
+		tmpl := parseIndex(templateFS)
+		tmpl.ExecuteTemplate(w, "bulk", data)
+	}
+}
+
+func parseIndex(templateFS embed.FS) *template.Template {
+	funcMap := template.FuncMap{
+		"shortenID":    ShortenID,
+		"shortenName":  ShortenName,
+		"urlQuery":     template.URLQueryEscaper,
+		"actionButton": NewActionButton,
+		"tabButton":    NewTabButton,
+	}
+
+	return template.Must(template.New("index.gohtml").Funcs(funcMap).ParseFS(templateFS, "cmd/containers/index.gohtml"))
+}
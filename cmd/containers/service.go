@@ -16,6 +16,19 @@
 
 package containers
 
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+
+	"github.com/dwui/cmd/docker"
+)
+
 func ShortenID(id string) string {
 	return shorten(id, 12)
 }
@@ -33,3 +46,216 @@ func shorten(text string, amount int) string {
 	}
 	return text
 }
+
+// LifecycleFunc is a lifecycle operation that can be applied to a single container
+type LifecycleFunc func(ctx context.Context, cli *client.Client, containerID string) error
+
+var actions = map[string]LifecycleFunc{
+	"start": func(ctx context.Context, cli *client.Client, containerID string) error {
+		return cli.ContainerStart(ctx, containerID, containertypes.StartOptions{})
+	},
+	"stop": func(ctx context.Context, cli *client.Client, containerID string) error {
+		return cli.ContainerStop(ctx, containerID, containertypes.StopOptions{})
+	},
+	"restart": func(ctx context.Context, cli *client.Client, containerID string) error {
+		return cli.ContainerRestart(ctx, containerID, containertypes.StopOptions{})
+	},
+	"pause": func(ctx context.Context, cli *client.Client, containerID string) error {
+		return cli.ContainerPause(ctx, containerID)
+	},
+	"unpause": func(ctx context.Context, cli *client.Client, containerID string) error {
+		return cli.ContainerUnpause(ctx, containerID)
+	},
+	"kill": func(ctx context.Context, cli *client.Client, containerID string) error {
+		return cli.ContainerKill(ctx, containerID, "SIGKILL")
+	},
+	"remove": func(ctx context.Context, cli *client.Client, containerID string) error {
+		return cli.ContainerRemove(ctx, containerID, containertypes.RemoveOptions{Force: true})
+	},
+}
+
+// LookupAction returns the lifecycle action registered under name
+func LookupAction(name string) (LifecycleFunc, bool) {
+	action, ok := actions[name]
+	return action, ok
+}
+
+// FindContainer returns the summary of a single container, including stopped
+// ones. The boolean is false when the container no longer exists.
+func FindContainer(ctx context.Context, cli *client.Client, containerID string) (containertypes.Summary, bool, error) {
+	found, err := ListByIDs(ctx, cli, []string{containerID})
+	if err != nil {
+		return containertypes.Summary{}, false, err
+	}
+	container, ok := found[containerID]
+	return container, ok, nil
+}
+
+// ListByIDs returns the summaries of the given containers keyed by full ID.
+// Containers that no longer exist are absent from the map. The container
+// cache is updated with whatever the daemon reported.
+func ListByIDs(ctx context.Context, cli *client.Client, containerIDs []string) (map[string]containertypes.Summary, error) {
+	found := map[string]containertypes.Summary{}
+	if len(containerIDs) == 0 {
+		return found, nil
+	}
+
+	refreshes.Lock()
+	defer refreshes.Unlock()
+
+	list, err := cli.ContainerList(ctx, containertypes.ListOptions{All: true, Filters: idFilter(containerIDs)})
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range list {
+		found[container.ID] = container
+	}
+
+	store(containerIDs, found)
+	return found, nil
+}
+
+// bulkWorkers bounds how many containers a bulk action touches at once
+const bulkWorkers = 4
+
+// BulkResult is the outcome of a bulk action for a single container
+type BulkResult struct {
+	ContainerID string
+	Error       error
+}
+
+// RunBulk applies action to every container using a bounded worker pool.
+// A failing container does not stop the rest of the batch; results are
+// returned in the same order as containerIDs.
+func RunBulk(ctx context.Context, cli *client.Client, action LifecycleFunc, containerIDs []string) []BulkResult {
+	results := make([]BulkResult, len(containerIDs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(bulkWorkers, len(containerIDs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = BulkResult{
+					ContainerID: containerIDs[i],
+					Error:       action(ctx, cli, containerIDs[i]),
+				}
+			}
+		}()
+	}
+
+	for i := range containerIDs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// States lists the container states that can be used to filter the list
+var States = []string{"created", "running", "restarting", "paused", "exited", "removing", "dead"}
+
+// ListFilter holds the list page filters. It round-trips through the URL
+// query so a filtered view can be bookmarked and reloaded.
+type ListFilter struct {
+	All   bool
+	State string
+	Name  string
+	Image string
+	Label string
+}
+
+func ParseListFilter(query url.Values) ListFilter {
+	return ListFilter{
+		All:   query.Get("all") == "1",
+		State: strings.TrimSpace(query.Get("state")),
+		Name:  strings.TrimSpace(query.Get("name")),
+		Image: strings.TrimSpace(query.Get("image")),
+		Label: strings.TrimSpace(query.Get("label")),
+	}
+}
+
+// Options translates the filter into Docker list options, so names match
+// as regular expressions and images by reference, ID or ancestor exactly
+// like `docker ps --filter`. Without a state filter only running containers
+// are listed unless All is set.
+func (f ListFilter) Options() containertypes.ListOptions {
+	args := filters.NewArgs()
+	if f.State != "" {
+		args.Add("status", f.State)
+	}
+	if f.Name != "" {
+		args.Add("name", f.Name)
+	}
+	if f.Image != "" {
+		args.Add("ancestor", f.Image)
+	}
+	if f.Label != "" {
+		args.Add("label", f.Label)
+	}
+
+	return containertypes.ListOptions{
+		All:     f.All || f.State != "",
+		Filters: args,
+	}
+}
+
+// cacheable reports whether the filter can be answered from the container
+// cache. State and label filters compare summary fields the same way the
+// daemon does, name and image filters need the daemon.
+func (f ListFilter) cacheable() bool {
+	return f.Name == "" && f.Image == ""
+}
+
+// matchCached applies a cacheable filter to a summary
+func (f ListFilter) matchCached(container containertypes.Summary) bool {
+	switch {
+	case f.State != "":
+		if container.State != f.State {
+			return false
+		}
+	case !f.All:
+		if container.State != "running" {
+			return false
+		}
+	}
+	return docker.HasLabel(container.Labels, f.Label)
+}
+
+// Matches reports whether a container passes the filter
+func (f ListFilter) Matches(ctx context.Context, cli *client.Client, container containertypes.Summary) (bool, error) {
+	if f.cacheable() {
+		return f.matchCached(container), nil
+	}
+
+	options := f.Options()
+	options.Filters.Add("id", container.ID)
+	list, err := cli.ContainerList(ctx, options)
+	if err != nil {
+		return false, err
+	}
+	return len(list) > 0, nil
+}
+
+// Query encodes the filter back into URL query values, omitting empty ones
+func (f ListFilter) Query() url.Values {
+	query := url.Values{}
+	if f.All {
+		query.Set("all", "1")
+	}
+	if f.State != "" {
+		query.Set("state", f.State)
+	}
+	if f.Name != "" {
+		query.Set("name", f.Name)
+	}
+	if f.Image != "" {
+		query.Set("image", f.Image)
+	}
+	if f.Label != "" {
+		query.Set("label", f.Label)
+	}
+	return query
+}
@@ -0,0 +1,87 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package diff
+
+import (
+	"context"
+	"embed"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/dwui/cmd/containers"
+	"github.com/dwui/cmd/docker"
+)
+
+type ShowPageData struct {
+	ContainerID   string
+	ContainerName string
+	Root          *Node
+	Summary       Summary
+	Error         string
+}
+
+// DownloadURL returns the file browser URL that downloads p. Directories are
+// sent as tar archives.
+func (data ShowPageData) DownloadURL(p string) string {
+	return "/files/download/" + data.ContainerID + "?" + url.Values{"path": {p}}.Encode()
+}
+
+func (data ShowPageData) setDownloadURLs(node *Node) {
+	if node.Downloadable() {
+		node.DownloadURL = data.DownloadURL(node.Path)
+	}
+	for _, child := range node.Children {
+		data.setDownloadURLs(child)
+	}
+}
+
+func Show(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+		var containerName = req.URL.Query().Get("name")
+
+		if containerName == "" {
+			containerName = containers.ShortenID(containerID)
+		}
+
+		data := ShowPageData{
+			ContainerID:   containerID,
+			ContainerName: containerName,
+		}
+
+		ctx := context.Background()
+		cli, err := docker.Client()
+		if err != nil {
+			docker.RenderUnavailable(w, templateFS, err)
+			return
+		}
+
+		changes, err := cli.ContainerDiff(ctx, containerID)
+		if err != nil {
+			data.Error = err.Error()
+		} else {
+			data.Root, data.Summary = BuildTree(changes)
+			data.setDownloadURLs(data.Root)
+		}
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/diff/show.gohtml"))
+		tmpl.Execute(w, data)
+	}
+}
@@ -0,0 +1,117 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package diff
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	containertypes "github.com/docker/docker/api/types/container"
+)
+
+// Node is an entry of the change tree. Directories that only contain
+// changes, without being changed themselves, have an empty Kind.
+type Node struct {
+	Name     string
+	Path     string
+	Kind     string
+	Children []*Node
+
+	// DownloadURL is set by the handler for nodes that can be downloaded
+	DownloadURL string
+
+	children map[string]*Node
+}
+
+// Summary counts the changes of a container by kind
+type Summary struct {
+	Added   int
+	Changed int
+	Deleted int
+}
+
+func kindName(kind containertypes.ChangeType) string {
+	switch kind {
+	case containertypes.ChangeAdd:
+		return "added"
+	case containertypes.ChangeDelete:
+		return "deleted"
+	default:
+		return "changed"
+	}
+}
+
+// BuildTree groups the flat list returned by `docker diff` into a tree rooted
+// at "/", with directories listed before files at every level
+func BuildTree(changes []containertypes.FilesystemChange) (*Node, Summary) {
+	root := &Node{Name: "/", Path: "/", children: map[string]*Node{}}
+	summary := Summary{}
+
+	for _, change := range changes {
+		node := root
+		for _, part := range strings.Split(strings.Trim(path.Clean("/"+change.Path), "/"), "/") {
+			if part == "" {
+				continue
+			}
+			child, ok := node.children[part]
+			if !ok {
+				child = &Node{Name: part, Path: path.Join(node.Path, part), children: map[string]*Node{}}
+				node.children[part] = child
+			}
+			node = child
+		}
+
+		node.Kind = kindName(change.Kind)
+		switch change.Kind {
+		case containertypes.ChangeAdd:
+			summary.Added++
+		case containertypes.ChangeDelete:
+			summary.Deleted++
+		default:
+			summary.Changed++
+		}
+	}
+
+	root.sort()
+	return root, summary
+}
+
+func (n *Node) sort() {
+	n.Children = make([]*Node, 0, len(n.children))
+	for _, child := range n.children {
+		child.sort()
+		n.Children = append(n.Children, child)
+	}
+
+	slices.SortFunc(n.Children, func(a, b *Node) int {
+		if len(a.Children) > 0 != (len(b.Children) > 0) {
+			if len(a.Children) > 0 {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// Downloadable reports whether the node is a leaf that still exists in the
+// container and was touched since it started. Directories that only hold
+// other changes are left out, they can be large.
+func (n *Node) Downloadable() bool {
+	return len(n.Children) == 0 && (n.Kind == "added" || n.Kind == "changed")
+}
@@ -0,0 +1,65 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package docker
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"net/url"
+)
+
+type UnavailablePageData struct {
+	Error string
+}
+
+// RenderUnavailable renders the error shown in place of a view while the
+// Docker daemon cannot be reached
+func RenderUnavailable(w http.ResponseWriter, templateFS embed.FS, err error) {
+	data := UnavailablePageData{
+		Error: err.Error(),
+	}
+
+	tmpl := template.Must(template.ParseFS(templateFS, "cmd/docker/unavailable.gohtml"))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	tmpl.Execute(w, data)
+}
+
+// ReplaceURL keeps the browser URL in sync with the view rendered into the
+// home page and its filters, so the view survives a reload. Empty filters
+// are left out; the containers list is the default view and has no name.
+func ReplaceURL(w http.ResponseWriter, view string, filters url.Values) {
+	page := url.Values{}
+	for key, values := range filters {
+		for _, value := range values {
+			if value != "" {
+				page.Add(key, value)
+			}
+		}
+	}
+	if view != "" {
+		page.Set("view", view)
+	}
+
+	target := "/"
+	if len(page) > 0 {
+		target += "?" + page.Encode()
+	}
+	w.Header().Set("HX-Replace-Url", target)
+}
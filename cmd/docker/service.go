@@ -0,0 +1,121 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+const (
+	healthInterval = 10 * time.Second
+	pingTimeout    = 5 * time.Second
+)
+
+var (
+	mu        sync.RWMutex
+	instance  *client.Client
+	lastError error = errors.New("Docker client not initialized")
+)
+
+// Init creates the Docker client shared by the whole server and starts
+// checking the daemon health in the background. The daemon does not need to
+// be reachable for Init to succeed.
+func Init() {
+	check()
+
+	go func() {
+		ticker := time.NewTicker(healthInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			check()
+		}
+	}()
+}
+
+// Client returns the shared Docker client, or an error describing why the
+// daemon is currently unavailable. Callers must not close the client.
+func Client() (*client.Client, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if lastError != nil {
+		return nil, fmt.Errorf("Docker daemon unavailable: %w", lastError)
+	}
+	return instance, nil
+}
+
+// check pings the daemon and records the result. A failed ping replaces the
+// client so that a restarted daemon or re-created socket is picked up.
+func check() {
+	mu.RLock()
+	cli := instance
+	mu.RUnlock()
+
+	if cli == nil {
+		var err error
+		cli, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			setState(nil, err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	_, err := cli.Ping(ctx)
+	if err != nil {
+		cli.Close()
+		setState(nil, err)
+		return
+	}
+	setState(cli, nil)
+}
+
+func setState(cli *client.Client, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err != nil && lastError == nil {
+		log.Println("Docker daemon unavailable:", err)
+	}
+	if err == nil && lastError != nil {
+		log.Println("Docker daemon connected")
+	}
+
+	instance = cli
+	lastError = err
+}
+
+// HasLabel reports whether labels hold label, given as "key" or "key=value"
+// like the label filter of the Docker API. An empty label matches everything.
+func HasLabel(labels map[string]string, label string) bool {
+	if label == "" {
+		return true
+	}
+	key, value, hasValue := strings.Cut(label, "=")
+	actual, ok := labels[key]
+	return ok && (!hasValue || actual == value)
+}
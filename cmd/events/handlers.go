@@ -0,0 +1,62 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/dwui/cmd/docker"
+)
+
+type IndexPageData struct {
+	Filter    Filter
+	Types     []string
+	StreamURL string
+}
+
+func Index(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		filter := Filter{
+			Type:   query.Get("type"),
+			Action: query.Get("action"),
+			Name:   query.Get("name"),
+		}
+
+		stream := url.Values{"backlog": {"1"}}
+		page := url.Values{"type": {filter.Type}, "action": {filter.Action}, "name": {filter.Name}}
+		for key, values := range page {
+			if values[0] != "" {
+				stream[key] = values
+			}
+		}
+
+		data := IndexPageData{
+			Filter:    filter,
+			Types:     Types,
+			StreamURL: "/events/stream?" + stream.Encode(),
+		}
+
+		docker.ReplaceURL(w, "events", page)
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/events/index.gohtml"))
+		tmpl.Execute(w, data)
+	}
+}
@@ -0,0 +1,192 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+
+	"github.com/dwui/cmd/docker"
+)
+
+// historySize is how many recent events are kept for the timeline
+const historySize = 500
+
+// Types lists the event types offered by the timeline filter
+var Types = []string{"container", "image", "network", "volume", "daemon", "plugin"}
+
+// Event is the browser facing view of a Docker event
+type Event struct {
+	Time       time.Time         `json:"time"`
+	Type       string            `json:"type"`
+	Action     string            `json:"action"`
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Image      string            `json:"image"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+func newEvent(message eventtypes.Message) Event {
+	return Event{
+		Time:       time.Unix(0, message.TimeNano),
+		Type:       string(message.Type),
+		Action:     string(message.Action),
+		ID:         message.Actor.ID,
+		Name:       message.Actor.Attributes["name"],
+		Image:      message.Actor.Attributes["image"],
+		Attributes: message.Actor.Attributes,
+	}
+}
+
+// Filter selects events by type, action prefix and actor name substring.
+// Empty fields match everything.
+type Filter struct {
+	Type   string
+	Action string
+	Name   string
+}
+
+func (f Filter) Match(event Event) bool {
+	if f.Type != "" && event.Type != f.Type {
+		return false
+	}
+	if f.Action != "" && !strings.HasPrefix(event.Action, f.Action) {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(event.Name, f.Name) {
+		return false
+	}
+	return true
+}
+
+var (
+	mu           sync.Mutex
+	history      []Event
+	subscribers  = map[chan Event]struct{}{}
+	connectHooks []func()
+	eventHooks   []func(Event)
+)
+
+// OnEvent registers a function called for every event, in order and without
+// dropping any. Hooks run on the stream goroutine and must not block.
+func OnEvent(hook func(Event)) {
+	mu.Lock()
+	defer mu.Unlock()
+	eventHooks = append(eventHooks, hook)
+}
+
+// OnConnect registers a function called every time the event stream is
+// (re)established. Events may have been missed while it was down, so
+// listeners use it to resynchronize their state.
+func OnConnect(hook func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	connectHooks = append(connectHooks, hook)
+}
+
+// Start subscribes to the Docker event stream once for the whole server and
+// fans events out to every subscriber. The subscription is re-established
+// when the daemon connection drops.
+func Start() {
+	go func() {
+		for {
+			if err := listen(); err != nil {
+				log.Println("Docker events stream error:", err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}()
+}
+
+func listen() error {
+	cli, err := docker.Client()
+	if err != nil {
+		// The health check already reports an unavailable daemon
+		return nil
+	}
+
+	messages, errs := cli.Events(context.Background(), eventtypes.ListOptions{})
+
+	mu.Lock()
+	hooks := connectHooks
+	mu.Unlock()
+	for _, hook := range hooks {
+		go hook()
+	}
+
+	for {
+		select {
+		case message := <-messages:
+			publish(newEvent(message))
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+func publish(event Event) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, hook := range eventHooks {
+		hook(event)
+	}
+
+	history = append(history, event)
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+
+	for subscriber := range subscribers {
+		// Slow subscribers miss events rather than stalling everyone else
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a new listener. It returns the recent events that match
+// filter, oldest first, along with a channel of live events and a function
+// that cancels the subscription.
+func Subscribe(filter Filter) ([]Event, <-chan Event, func()) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	recent := []Event{}
+	for _, event := range history {
+		if filter.Match(event) {
+			recent = append(recent, event)
+		}
+	}
+
+	subscriber := make(chan Event, 64)
+	subscribers[subscriber] = struct{}{}
+
+	unsubscribe := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(subscribers, subscriber)
+	}
+
+	return recent, subscriber, unsubscribe
+}
@@ -0,0 +1,82 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// Socket streams Docker events matching the type, action and name query
+// parameters. With backlog=1 the recent matching events are sent first.
+func Socket(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := Filter{
+		Type:   query.Get("type"),
+		Action: query.Get("action"),
+		Name:   query.Get("name"),
+	}
+
+	var upgrader = websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true // for local dev, allow all origins
+		},
+	}
+	wsConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		http.Error(w, "WebSocket upgrade failed", http.StatusInternalServerError)
+		return
+	}
+	defer wsConn.Close()
+
+	recent, live, unsubscribe := Subscribe(filter)
+	defer unsubscribe()
+
+	// Notice when the browser goes away even if no events are flowing
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := wsConn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if query.Get("backlog") == "1" {
+		for _, event := range recent {
+			if err := wsConn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case event := <-live:
+			if !filter.Match(event) {
+				continue
+			}
+			if err := wsConn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/dwui/cmd/containers"
+	"github.com/dwui/cmd/docker"
+)
+
+type ShowPageData struct {
+	ContainerID   string
+	ContainerName string
+	Path          string
+	Breadcrumbs   []Breadcrumb
+	Entries       []Entry
+	Truncated     bool
+	Error         string
+}
+
+type UploadPageData struct {
+	Path    string
+	Results []UploadResult
+	Error   string
+}
+
+type Breadcrumb struct {
+	Name string
+	Path string
+}
+
+type PreviewPageData struct {
+	ContainerID string
+	Path        string
+	Content     string
+	IsText      bool
+	Truncated   bool
+	Error       string
+}
+
+// BrowseURL returns the URL that lists the directory p
+func (data ShowPageData) BrowseURL(p string) string {
+	query := url.Values{}
+	query.Set("name", data.ContainerName)
+	query.Set("path", p)
+	return "/files/" + data.ContainerID + "?" + query.Encode()
+}
+
+// PreviewURL returns the URL that renders the file p inline
+func (data ShowPageData) PreviewURL(p string) string {
+	return "/files/preview/" + data.ContainerID + "?" + url.Values{"path": {p}}.Encode()
+}
+
+// DownloadURL returns the URL that downloads p in the given format. An empty
+// format downloads a single file as-is.
+func (data ShowPageData) DownloadURL(p string, format string) string {
+	query := url.Values{}
+	query.Set("path", p)
+	if format != "" {
+		query.Set("format", format)
+	}
+	return "/files/download/" + data.ContainerID + "?" + query.Encode()
+}
+
+func Show(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+		var containerName = req.URL.Query().Get("name")
+
+		if containerName == "" {
+			containerName = containers.ShortenID(containerID)
+		}
+
+		data := ShowPageData{
+			ContainerID:   containerID,
+			ContainerName: containerName,
+			Path:          CleanPath(req.URL.Query().Get("path")),
+		}
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/files/show.gohtml"))
+
+		ctx := context.Background()
+		cli, err := docker.Client()
+		if err != nil {
+			docker.RenderUnavailable(w, templateFS, err)
+			return
+		}
+
+		resolved, mode, err := Resolve(ctx, cli, containerID, data.Path)
+		if err == nil && !mode.IsDir() {
+			err = fmt.Errorf("%s is not a directory", data.Path)
+		}
+		if err == nil {
+			data.Path = resolved
+			data.Entries, data.Truncated, err = ListDir(ctx, cli, containerID, data.Path)
+		}
+		if err != nil {
+			data.Error = err.Error()
+		}
+		data.Breadcrumbs = breadcrumbs(data.Path)
+
+		tmpl.Execute(w, data)
+	}
+}
+
+func Preview(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+
+		data := PreviewPageData{
+			ContainerID: containerID,
+			Path:        CleanPath(req.URL.Query().Get("path")),
+		}
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/files/show.gohtml"))
+
+		ctx := context.Background()
+		cli, err := docker.Client()
+		if err != nil {
+			docker.RenderUnavailable(w, templateFS, err)
+			return
+		}
+
+		content, isText, truncated, err := ReadPreview(ctx, cli, containerID, data.Path)
+		if err != nil {
+			data.Error = err.Error()
+		} else {
+			data.Content = string(content)
+			data.IsText = isText
+			data.Truncated = truncated
+		}
+
+		tmpl.ExecuteTemplate(w, "preview", data)
+	}
+}
+
+// Download streams a file as-is, or a file or directory as a tar or zip
+// archive. Nothing is buffered in memory.
+func Download(w http.ResponseWriter, req *http.Request) {
+	var containerID = chi.URLParam(req, "containerID")
+	var p = CleanPath(req.URL.Query().Get("path"))
+	var format = req.URL.Query().Get("format")
+
+	ctx := context.Background()
+	cli, err := docker.Client()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Stat first so errors can still be reported before the body starts
+	stat, err := cli.ContainerStatPath(ctx, containerID, p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if format == "" && !stat.Mode.IsRegular() {
+		format = "tar"
+	}
+
+	filename := path.Base(p)
+	if filename == "/" {
+		filename = "root"
+	}
+
+	switch format {
+	case "tar":
+		setAttachment(w, filename+".tar", "application/x-tar")
+		err = WriteTar(ctx, cli, containerID, p, w)
+	case "zip":
+		setAttachment(w, filename+".zip", "application/zip")
+		err = WriteZip(ctx, cli, containerID, p, w)
+	default:
+		setAttachment(w, filename, "application/octet-stream")
+		err = WriteFile(ctx, cli, containerID, p, w)
+	}
+
+	if err != nil {
+		log.Printf("download of %s from %s failed: %v", p, containers.ShortenID(containerID), err)
+	}
+}
+
+// Upload reads the multipart form part by part and streams every file into
+// the container. The path and overwrite fields must come before the files,
+// which is the order browsers send them in.
+func Upload(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/files/show.gohtml"))
+
+		req.Body = http.MaxBytesReader(w, req.Body, MaxUploadSize)
+		reader, err := req.MultipartReader()
+		if err != nil {
+			http.Error(w, "Invalid upload", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.Background()
+		cli, err := docker.Client()
+		if err != nil {
+			docker.RenderUnavailable(w, templateFS, err)
+			return
+		}
+
+		data := UploadPageData{Path: "/"}
+		overwrite := false
+		var upload *Uploader
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				data.Error = err.Error()
+				break
+			}
+
+			switch part.FormName() {
+			case "path":
+				data.Path = CleanPath(readField(part))
+			case "overwrite":
+				overwrite = readField(part) == "1"
+			case "files":
+				if part.FileName() == "" {
+					break
+				}
+				if upload == nil {
+					upload, err = NewUploader(ctx, cli, containerID, data.Path)
+					if err != nil {
+						data.Error = err.Error()
+						break
+					}
+				}
+				data.Results = append(data.Results, upload.Add(part.FileName(), part, overwrite))
+			}
+			part.Close()
+
+			if data.Error != "" {
+				break
+			}
+		}
+
+		// Files only reach the container once the archive is sent, a rejected
+		// archive fails every file of it
+		if upload != nil {
+			if err := upload.Close(); err != nil {
+				if data.Error == "" {
+					data.Error = err.Error()
+				}
+				for i := range data.Results {
+					if data.Results[i].Error == "" {
+						data.Results[i].Error = err.Error()
+					}
+				}
+			}
+		}
+		if data.Error != "" {
+			log.Printf("upload to %s failed: %s", containers.ShortenID(containerID), data.Error)
+		}
+
+		tmpl.ExecuteTemplate(w, "upload", data)
+	}
+}
+
+// readField reads a small text form field
+func readField(part io.Reader) string {
+	value, _ := io.ReadAll(io.LimitReader(part, 4096))
+	return strings.TrimSpace(string(value))
+}
+
+func setAttachment(w http.ResponseWriter, filename string, contentType string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+}
+
+func breadcrumbs(p string) []Breadcrumb {
+	crumbs := []Breadcrumb{{Name: "/", Path: "/"}}
+	current := "/"
+	for _, part := range strings.Split(strings.Trim(p, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		current = path.Join(current, part)
+		crumbs = append(crumbs, Breadcrumb{Name: part, Path: current})
+	}
+	return crumbs
+}
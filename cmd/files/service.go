@@ -0,0 +1,445 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// The daemon streams the whole subtree of a listed directory, file bodies
+// included, and the direct children are spread across it. These bound how
+// much of the archive is scanned before the listing is cut short.
+const (
+	maxListedEntries = 20000
+	maxListedBytes   = 64 * 1024 * 1024
+	listTimeout      = 10 * time.Second
+)
+
+// PreviewLimit is the largest amount of a file rendered inline
+const PreviewLimit = 512 * 1024
+
+// Entry is a direct child of a listed directory
+type Entry struct {
+	Name       string
+	Path       string
+	Size       int64
+	Mode       fs.FileMode
+	ModTime    time.Time
+	LinkTarget string
+}
+
+func (e Entry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+func (e Entry) IsRegular() bool {
+	return e.Mode.IsRegular()
+}
+
+func (e Entry) IsLink() bool {
+	return e.Mode&fs.ModeSymlink != 0
+}
+
+// SizeLabel formats the entry size for humans, leaving directories blank
+func (e Entry) SizeLabel() string {
+	if e.IsDir() {
+		return ""
+	}
+
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	size := float64(e.Size)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", e.Size)
+	}
+	return fmt.Sprintf("%.1f %s", size, units[unit])
+}
+
+// CleanPath turns user input into an absolute, normalized container path
+func CleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// Resolve follows a symbolic link so that linked directories can be browsed.
+// The daemon already reports link targets as absolute paths.
+func Resolve(ctx context.Context, cli *client.Client, containerID string, p string) (string, fs.FileMode, error) {
+	stat, err := cli.ContainerStatPath(ctx, containerID, p)
+	if err != nil {
+		return "", 0, err
+	}
+	if stat.Mode&fs.ModeSymlink == 0 || stat.LinkTarget == "" {
+		return p, stat.Mode, nil
+	}
+
+	target, err := cli.ContainerStatPath(ctx, containerID, stat.LinkTarget)
+	if err != nil {
+		return "", 0, err
+	}
+	return CleanPath(stat.LinkTarget), target.Mode, nil
+}
+
+// ListDir returns the direct children of dir, directories first. The boolean
+// is true when the directory was too large to be scanned completely.
+func ListDir(ctx context.Context, cli *client.Client, containerID string, dir string) ([]Entry, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, listTimeout)
+	defer cancel()
+
+	content, _, err := cli.CopyFromContainer(ctx, containerID, dir)
+	if err != nil {
+		return nil, false, err
+	}
+	defer content.Close()
+
+	entries := []Entry{}
+	truncated := false
+	reader := tar.NewReader(&limitedReader{r: content, remaining: maxListedBytes})
+
+	// The first header is the directory itself; its name is the prefix of
+	// every other entry in the archive
+	root := ""
+	for scanned := 0; ; scanned++ {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if scanned > 0 && (errors.Is(err, errLimitReached) || ctx.Err() != nil) {
+			truncated = true
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if scanned >= maxListedEntries {
+			truncated = true
+			break
+		}
+
+		name := strings.Trim(path.Clean("/"+header.Name), "/")
+		if scanned == 0 {
+			root = name
+			continue
+		}
+
+		relative := name
+		if root != "" {
+			relative = strings.TrimPrefix(name, root+"/")
+		}
+		if relative == "" || strings.Contains(relative, "/") {
+			continue
+		}
+
+		entries = append(entries, Entry{
+			Name:       relative,
+			Path:       path.Join(dir, relative),
+			Size:       header.Size,
+			Mode:       header.FileInfo().Mode(),
+			ModTime:    header.ModTime,
+			LinkTarget: header.Linkname,
+		})
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		if a.IsDir() != b.IsDir() {
+			if a.IsDir() {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return entries, truncated, nil
+}
+
+// ReadPreview returns up to PreviewLimit bytes of a file. The booleans report
+// whether the content looks like text and whether it was cut off.
+func ReadPreview(ctx context.Context, cli *client.Client, containerID string, p string) ([]byte, bool, bool, error) {
+	var buf bytes.Buffer
+	writer := &limitedWriter{w: &buf, remaining: PreviewLimit}
+	err := WriteFile(ctx, cli, containerID, p, writer)
+	if err != nil && !errors.Is(err, errLimitReached) {
+		return nil, false, false, err
+	}
+
+	content := buf.Bytes()
+	truncated := errors.Is(err, errLimitReached)
+
+	// Drop a multi-byte character that was cut in half by the limit
+	for i := 0; truncated && i < utf8.UTFMax-1 && !utf8.Valid(content); i++ {
+		content = content[:len(content)-1]
+	}
+
+	isText := utf8.Valid(content) && !bytes.ContainsRune(content, 0)
+	return content, isText, truncated, nil
+}
+
+// WriteFile streams the content of a single regular file to w
+func WriteFile(ctx context.Context, cli *client.Client, containerID string, p string, w io.Writer) error {
+	content, stat, err := cli.CopyFromContainer(ctx, containerID, p)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if !stat.Mode.IsRegular() {
+		return errors.New("not a regular file")
+	}
+
+	reader := tar.NewReader(content)
+	if _, err := reader.Next(); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, reader)
+	return err
+}
+
+// WriteTar streams a file or directory to w as the tar archive produced by
+// the daemon
+func WriteTar(ctx context.Context, cli *client.Client, containerID string, p string, w io.Writer) error {
+	content, _, err := cli.CopyFromContainer(ctx, containerID, p)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	_, err = io.Copy(w, content)
+	return err
+}
+
+// WriteZip converts the daemon's tar archive into a zip archive on the fly.
+// Symbolic links are stored the way zip tools expect, with the target as
+// the entry content; other special files are skipped.
+func WriteZip(ctx context.Context, cli *client.Client, containerID string, p string, w io.Writer) error {
+	content, _, err := cli.CopyFromContainer(ctx, containerID, p)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	reader := tar.NewReader(content)
+	archive := zip.NewWriter(w)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeReg, tar.TypeSymlink:
+		default:
+			continue
+		}
+
+		zipHeader, err := zip.FileInfoHeader(header.FileInfo())
+		if err != nil {
+			return err
+		}
+		zipHeader.Name = strings.TrimPrefix(header.Name, "/")
+		if header.Typeflag == tar.TypeDir {
+			zipHeader.Name = strings.TrimSuffix(zipHeader.Name, "/") + "/"
+		} else {
+			zipHeader.Method = zip.Deflate
+		}
+
+		entry, err := archive.CreateHeader(zipHeader)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			_, err = io.Copy(entry, reader)
+		case tar.TypeSymlink:
+			_, err = io.WriteString(entry, header.Linkname)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+var errLimitReached = errors.New("limit reached")
+
+// limitedWriter accepts writes until remaining bytes are used up, then
+// fails so the copy stops early
+type limitedWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining {
+		n, _ := l.w.Write(p[:l.remaining])
+		l.remaining = 0
+		return n, errLimitReached
+	}
+	n, err := l.w.Write(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// limitedReader reads until remaining bytes are used up, then fails so the
+// archive is not read any further
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, errLimitReached
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// MaxUploadSize caps the size of a whole upload request
+const MaxUploadSize = 512 * 1024 * 1024
+
+// UploadResult is the outcome of uploading a single file
+type UploadResult struct {
+	Name  string
+	Size  int64
+	Error string
+}
+
+// Uploader streams files into a container directory as a single tar archive.
+// Each file is spooled to a temporary file first, since tar headers need the
+// size up front and multipart parts do not carry it.
+type Uploader struct {
+	ctx         context.Context
+	cli         *client.Client
+	containerID string
+	dir         string
+	pipe        *io.PipeWriter
+	archive     *tar.Writer
+	done        chan error
+}
+
+// NewUploader checks that dir is a directory and opens the copy stream
+func NewUploader(ctx context.Context, cli *client.Client, containerID string, dir string) (*Uploader, error) {
+	dir, mode, err := Resolve(ctx, cli, containerID, dir)
+	if err != nil {
+		return nil, err
+	}
+	if !mode.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	reader, writer := io.Pipe()
+	upload := &Uploader{
+		ctx:         ctx,
+		cli:         cli,
+		containerID: containerID,
+		dir:         dir,
+		pipe:        writer,
+		archive:     tar.NewWriter(writer),
+		done:        make(chan error, 1),
+	}
+
+	go func() {
+		err := cli.CopyToContainer(ctx, containerID, dir, reader, containertypes.CopyToContainerOptions{})
+		reader.CloseWithError(err)
+		upload.done <- err
+	}()
+
+	return upload, nil
+}
+
+// Add writes one file into the archive. Existing files are only replaced
+// when overwrite is set.
+func (u *Uploader) Add(name string, content io.Reader, overwrite bool) UploadResult {
+	result := UploadResult{Name: path.Base(CleanPath(name))}
+	if result.Name == "/" {
+		result.Error = "invalid file name"
+		return result
+	}
+
+	target := path.Join(u.dir, result.Name)
+	if _, err := u.cli.ContainerStatPath(u.ctx, u.containerID, target); err == nil && !overwrite {
+		result.Error = "already exists"
+		return result
+	}
+
+	spool, err := os.CreateTemp("", "dwui-upload-*")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	result.Size, err = io.Copy(spool, content)
+	if err == nil {
+		_, err = spool.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = u.archive.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     result.Name,
+			Mode:     0644,
+			Size:     result.Size,
+			ModTime:  time.Now(),
+		})
+	}
+	if err == nil {
+		_, err = io.Copy(u.archive, spool)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Close finishes the archive and waits for the daemon to extract it
+func (u *Uploader) Close() error {
+	err := u.archive.Close()
+	u.pipe.CloseWithError(err)
+	if copyErr := <-u.done; copyErr != nil {
+		return copyErr
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package forward
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lokiSink pushes records to a Loki compatible HTTP API. Credentials in the
+// URL are sent as basic authentication.
+type lokiSink struct {
+	url    string
+	client *http.Client
+}
+
+func newLokiSink(target string) (*lokiSink, error) {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Loki URL %q: must be an http or https push endpoint", target)
+	}
+	return &lokiSink{url: target, client: &http.Client{}}, nil
+}
+
+func (s *lokiSink) Name() string {
+	return "loki"
+}
+
+// lokiStream is a set of lines sharing the same labels in a push request.
+// Each value is a [timestamp, line] pair, followed by the structured
+// metadata of the line when it has any.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][]any           `json:"values"`
+}
+
+// Send groups the records into streams by container, image, stream and
+// level and pushes them in a single request. Docker labels travel as
+// structured metadata of each line, so they neither multiply the streams
+// nor count against the label limit of Loki. Rejected requests other than
+// rate limits are not retried.
+func (s *lokiSink) Send(ctx context.Context, records []Record) error {
+	streams := []*lokiStream{}
+	byLabels := map[string]*lokiStream{}
+	for _, record := range records {
+		labels := lokiLabels(record)
+		key := fmt.Sprint(labels)
+		stream, ok := byLabels[key]
+		if !ok {
+			stream = &lokiStream{Stream: labels}
+			byLabels[key] = stream
+			streams = append(streams, stream)
+		}
+
+		stamp, err := time.Parse(time.RFC3339Nano, record.Line.Time)
+		if err != nil {
+			stamp = time.Now()
+		}
+		value := []any{strconv.FormatInt(stamp.UnixNano(), 10), record.Line.Text}
+		if metadata := lokiMetadata(record); len(metadata) > 0 {
+			value = append(value, metadata)
+		}
+		stream.Values = append(stream.Values, value)
+	}
+
+	body, err := json.Marshal(map[string][]*lokiStream{"streams": streams})
+	if err != nil {
+		return permanentError{err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("loki answered %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
+
+// lokiLabels returns the stream labels of a record, kept to a few values
+// with a bounded number of combinations
+func lokiLabels(record Record) map[string]string {
+	labels := map[string]string{
+		"container": record.Container,
+		"image":     record.Image,
+		"stream":    record.Line.Stream,
+	}
+	if record.Line.Level != "" {
+		labels["level"] = record.Line.Level
+	}
+	return labels
+}
+
+// lokiMetadata returns the Docker labels of a record as structured metadata,
+// with their names reduced to the characters Loki accepts
+func lokiMetadata(record Record) map[string]string {
+	metadata := map[string]string{}
+	for key, value := range record.Labels {
+		metadata[lokiName(key)] = value
+	}
+	return metadata
+}
+
+// lokiName turns a Docker label into a valid Loki metadata name, replacing
+// anything but letters, digits and underscores
+func lokiName(key string) string {
+	var name strings.Builder
+	name.WriteString("label_")
+	for _, r := range key {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			name.WriteRune(r)
+		} else {
+			name.WriteRune('_')
+		}
+	}
+	return name.String()
+}
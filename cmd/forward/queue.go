@@ -0,0 +1,182 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package forward
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/dwui/cmd/database"
+)
+
+// queue is the bounded, disk backed buffer of records waiting for a sink.
+// Records are stored under increasing sequence numbers, so iterating the
+// prefix yields them in the order they were pushed.
+type queue struct {
+	sync.Mutex
+	name   string
+	max    int
+	next   uint64
+	length int
+	notify chan struct{}
+}
+
+// openQueue picks up the records left in the queue of a sink by a previous run
+func openQueue(name string, max int) (*queue, error) {
+	if database.Instance == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	q := &queue{name: name, max: max, notify: make(chan struct{}, 1)}
+	err := database.Instance.View(func(txn *badger.Txn) error {
+		prefix := q.prefix()
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			q.length++
+			q.next = binary.BigEndian.Uint64(bytes.TrimPrefix(it.Item().Key(), prefix)) + 1
+		}
+		return nil
+	})
+	return q, err
+}
+
+// push appends records to the queue, dropping the oldest ones when it
+// grows beyond its bound
+func (q *queue) push(records []Record) error {
+	q.Lock()
+	defer q.Unlock()
+
+	dropped := 0
+	err := database.Instance.Update(func(txn *badger.Txn) error {
+		for _, record := range records {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(q.key(q.next), data); err != nil {
+				return err
+			}
+			q.next++
+		}
+
+		overflow := q.length + len(records) - q.max
+		if overflow <= 0 {
+			return nil
+		}
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: q.prefix()})
+		defer it.Close()
+		for it.Rewind(); it.Valid() && dropped < overflow; it.Next() {
+			if err := txn.Delete(it.Item().KeyCopy(nil)); err != nil {
+				return err
+			}
+			dropped++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	q.length += len(records) - dropped
+	if dropped > 0 {
+		log.Printf("Log forwarding buffer for %s is full, dropped %d records", q.name, dropped)
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// peek returns up to limit of the oldest records with their keys, which are
+// handed back to ack once the records are delivered
+func (q *queue) peek(limit int) ([]Record, [][]byte, error) {
+	records := []Record{}
+	keys := [][]byte{}
+	err := database.Instance.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: limit, Prefix: q.prefix()})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid() && len(records) < limit; it.Next() {
+			var record Record
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &record)
+			})
+			if err != nil {
+				return err
+			}
+			records = append(records, record)
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	return records, keys, err
+}
+
+// ack removes delivered records. Records the overflow already dropped are
+// skipped.
+func (q *queue) ack(keys [][]byte) error {
+	q.Lock()
+	defer q.Unlock()
+
+	removed := 0
+	err := database.Instance.Update(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			if _, err := txn.Get(key); err == badger.ErrKeyNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err == nil {
+		q.length -= removed
+	}
+	return err
+}
+
+// wait blocks until records are pushed or timeout passes
+func (q *queue) wait(timeout time.Duration) {
+	select {
+	case <-q.notify:
+	case <-time.After(timeout):
+	}
+}
+
+func (q *queue) prefix() []byte {
+	return []byte("forward:queue:" + q.name + ":")
+}
+
+// key appends the sequence in big endian so keys sort in push order
+func (q *queue) key(sequence uint64) []byte {
+	return binary.BigEndian.AppendUint64(q.prefix(), sequence)
+}
@@ -0,0 +1,254 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package forward
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/dwui/cmd/database"
+	"github.com/dwui/cmd/logs"
+)
+
+const (
+	// pollInterval is how often an empty queue is checked when no push
+	// wakes its sender
+	pollInterval = time.Second
+
+	// batchSize is how many records are sent at once
+	batchSize = 500
+
+	// sendTimeout bounds how long a single delivery may take
+	sendTimeout = 10 * time.Second
+
+	// cursorRetention is how long a stopped container resumes where it left off
+	cursorRetention = 7 * 24 * time.Hour
+)
+
+// Record is a log line together with the container it was written by
+type Record struct {
+	ContainerID string            `json:"containerId"`
+	Container   string            `json:"container"`
+	Image       string            `json:"image"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Line        logs.Line         `json:"line"`
+}
+
+// Sink delivers batches of records to a log collector. Send returns a
+// permanentError when retrying the same batch cannot succeed.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, records []Record) error
+}
+
+// permanentError marks a batch the collector rejected for good
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+// Config selects the containers whose logs are forwarded and where to
+type Config struct {
+	// Syslog is the syslog server as udp://host:port or tcp://host:port
+	Syslog string
+	// Loki is the URL of a Loki compatible push API
+	Loki string
+	// Label ("key" or "key=value") restricts forwarding to the containers
+	// carrying it, all containers are forwarded when empty
+	Label string
+	// Buffer is how many records each sink may queue on disk while its
+	// collector is unreachable, the oldest are dropped beyond that
+	Buffer int
+}
+
+// Enabled reports whether any sink is configured
+func (c Config) Enabled() bool {
+	return c.Syslog != "" || c.Loki != ""
+}
+
+// queues holds the queue of every configured sink
+var queues []*queue
+
+// Start forwards the logs of the selected containers to every configured
+// sink. Lines are queued on disk per sink first, so they survive collector
+// outages and restarts, and are then sent in batches with retries.
+func Start(config Config) error {
+	sinks := []Sink{}
+	if config.Syslog != "" {
+		sink, err := newSyslogSink(config.Syslog)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, sink)
+	}
+	if config.Loki != "" {
+		sink, err := newLokiSink(config.Loki)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, sink)
+	}
+	if config.Buffer <= 0 {
+		return errors.New("log forwarding buffer must hold at least one record")
+	}
+
+	for _, sink := range sinks {
+		q, err := openQueue(sink.Name(), config.Buffer)
+		if err != nil {
+			return err
+		}
+		queues = append(queues, q)
+		go deliver(sink, q)
+	}
+
+	// Containers seen for the first time are forwarded from now on, the
+	// others from where their last tail stopped
+	tailer := &logs.Tailer{
+		Name:  "Log forwarding",
+		Label: config.Label,
+		Open:  openForward,
+	}
+	tailer.Start()
+	return nil
+}
+
+// openForward resumes forwarding a container after its last queued line
+func openForward(container logs.TailedContainer) (time.Time, func([]logs.Line) error, error) {
+	cursor, err := readCursor(container.ID)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	template := Record{
+		ContainerID: container.ID,
+		Container:   container.Name,
+		Image:       container.Image,
+		Labels:      container.Labels,
+	}
+	return cursor, func(batch []logs.Line) error {
+		records := make([]Record, len(batch))
+		for i, line := range batch {
+			records[i] = template
+			records[i].Line = line
+		}
+		// The cursor only moves once every queue holds the lines, so lines
+		// that could not be queued are read again on the next tail
+		for _, q := range queues {
+			if err := q.push(records); err != nil {
+				return fmt.Errorf("queue for %s: %v", q.name, err)
+			}
+		}
+
+		last, err := time.Parse(time.RFC3339Nano, batch[len(batch)-1].Time)
+		if err != nil {
+			return nil
+		}
+		return writeCursor(container.ID, last)
+	}, nil
+}
+
+// deliver sends the queued records of a sink in order, retrying failed
+// batches with a growing delay until the collector accepts them
+func deliver(sink Sink, q *queue) {
+	const (
+		minBackoff = time.Second
+		maxBackoff = time.Minute
+	)
+	backoff := minBackoff
+
+	for {
+		records, keys, err := q.peek(batchSize)
+		if err != nil {
+			log.Printf("Log forwarding queue error for %s: %v", q.name, err)
+			time.Sleep(backoff)
+			continue
+		}
+		if len(records) == 0 {
+			q.wait(pollInterval)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = sink.Send(ctx, records)
+		cancel()
+
+		var permanent permanentError
+		switch {
+		case err == nil:
+			backoff = minBackoff
+		case errors.As(err, &permanent):
+			log.Printf("Log forwarding to %s dropped %d records: %v", q.name, len(records), err)
+		default:
+			log.Printf("Log forwarding to %s failed, retrying in %s: %v", q.name, backoff, err)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxBackoff)
+			continue
+		}
+
+		if err := q.ack(keys); err != nil {
+			log.Printf("Log forwarding queue error for %s: %v", q.name, err)
+		}
+	}
+}
+
+func readCursor(containerID string) (time.Time, error) {
+	if database.Instance == nil {
+		return time.Time{}, fmt.Errorf("database not initialized")
+	}
+
+	var cursor time.Time
+	err := database.Instance.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(cursorKey(containerID))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &cursor)
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return time.Time{}, nil
+	}
+	return cursor, err
+}
+
+// writeCursor remembers the newest queued line of a container. Cursors of
+// containers that stay away expire.
+func writeCursor(containerID string, cursor time.Time) error {
+	if database.Instance == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return database.Instance.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(cursorKey(containerID), data).WithTTL(cursorRetention))
+	})
+}
+
+func cursorKey(containerID string) []byte {
+	return []byte("forward:cursor:" + containerID)
+}
@@ -0,0 +1,180 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package forward
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dwui/cmd/containers"
+)
+
+const (
+	// syslogFacility is the user-level messages facility
+	syslogFacility = 1
+
+	// syslogEnterprise identifies the structured data element holding the
+	// container metadata
+	syslogEnterprise = "dwui@32473"
+
+	// syslogDatagramSize is the largest UDP message sent, the size RFC 5424
+	// advises receivers to accept. Longer lines are cut, a datagram over the
+	// network limit could never be delivered.
+	syslogDatagramSize = 2048
+)
+
+// syslogSeverities maps detected log levels to syslog severities
+var syslogSeverities = map[string]int{
+	"trace": 7,
+	"debug": 7,
+	"info":  6,
+	"warn":  4,
+	"error": 3,
+	"fatal": 2,
+}
+
+// syslogSink writes RFC 5424 messages to a syslog server. TCP messages are
+// framed with octet counting (RFC 6587), UDP sends one datagram per line,
+// truncated to syslogDatagramSize.
+type syslogSink struct {
+	network  string
+	address  string
+	hostname string
+	conn     net.Conn
+}
+
+func newSyslogSink(target string) (*syslogSink, error) {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "udp" && parsed.Scheme != "tcp") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid syslog target %q: must be udp://host:port or tcp://host:port", target)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	return &syslogSink{network: parsed.Scheme, address: parsed.Host, hostname: hostname}, nil
+}
+
+func (s *syslogSink) Name() string {
+	return "syslog"
+}
+
+// Send writes the records over a connection that is kept open between
+// batches and dialed again after a failure
+func (s *syslogSink) Send(ctx context.Context, records []Record) error {
+	if s.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	}
+	for _, record := range records {
+		message := s.format(record)
+		if s.network == "tcp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		} else if len(message) > syslogDatagramSize {
+			message = strings.ToValidUTF8(message[:syslogDatagramSize], "")
+		}
+		if _, err := s.conn.Write([]byte(message)); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// format renders a record as an RFC 5424 message. The container name is the
+// app name, the short ID the process ID and the stream the message ID; the
+// image and labels go into a structured data element.
+func (s *syslogSink) format(record Record) string {
+	severity, ok := syslogSeverities[record.Line.Level]
+	if !ok {
+		severity = 6
+		if record.Line.Stream == "stderr" {
+			severity = 3
+		}
+	}
+
+	timestamp := "-"
+	if stamp, err := time.Parse(time.RFC3339Nano, record.Line.Time); err == nil {
+		timestamp = stamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+	}
+
+	data := []string{syslogEnterprise}
+	data = append(data, syslogParam("id", record.ContainerID), syslogParam("image", record.Image))
+	keys := make([]string, 0, len(record.Labels))
+	for key := range record.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if name := syslogName(key, 32); name != "-" {
+			data = append(data, syslogParam(name, record.Labels[key]))
+		}
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s [%s] %s",
+		syslogFacility*8+severity,
+		timestamp,
+		syslogName(s.hostname, 255),
+		syslogName(record.Container, 48),
+		syslogName(containers.ShortenID(record.ContainerID), 128),
+		syslogName(record.Line.Stream, 32),
+		strings.Join(data, " "),
+		record.Line.Text,
+	)
+}
+
+// syslogName keeps the printable ASCII characters a header field or
+// parameter name may hold, up to max of them. Empty names become the nil
+// value "-".
+func syslogName(value string, max int) string {
+	var name strings.Builder
+	for _, r := range value {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			continue
+		}
+		if name.Len() == max {
+			break
+		}
+		name.WriteRune(r)
+	}
+	if name.Len() == 0 {
+		return "-"
+	}
+	return name.String()
+}
+
+// syslogParam escapes the characters RFC 5424 reserves in parameter values
+func syslogParam(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+	return name + `="` + value + `"`
+}
@@ -23,13 +23,32 @@ import (
 )
 
 type ShowPageData struct {
-	PageTitle string
+	PageTitle  string
+	ContentURL string
 }
 
 func Show(templateFS embed.FS) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		data := ShowPageData{
-			PageTitle: "Dwui",
+			PageTitle:  "Dwui",
+			ContentURL: "/containers",
+		}
+
+		// Forward the view and its filters from the page URL to the content
+		query := req.URL.Query()
+		switch query.Get("view") {
+		case "events":
+			data.ContentURL = "/events"
+		case "archive":
+			data.ContentURL = "/logs/archive"
+		case "alerts":
+			data.ContentURL = "/alerts"
+		case "recordings":
+			data.ContentURL = "/terminal/recordings"
+		}
+		query.Del("view")
+		if encoded := query.Encode(); encoded != "" {
+			data.ContentURL += "?" + encoded
 		}
 
 		tmpl := template.Must(template.ParseFS(templateFS, "cmd/home/show.gohtml"))
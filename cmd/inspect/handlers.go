@@ -22,8 +22,9 @@ import (
 	"html/template"
 	"net/http"
 
-	"github.com/docker/docker/client"
 	"github.com/go-chi/chi/v5"
+
+	"github.com/dwui/cmd/docker"
 )
 
 type ShowPageData struct {
@@ -59,12 +60,11 @@ func Show(templateFS embed.FS) http.HandlerFunc {
 		}
 
 		ctx := context.Background()
-		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		cli, err := docker.Client()
 		if err != nil {
-			http.Error(w, "Docker client error", http.StatusInternalServerError)
+			docker.RenderUnavailable(w, templateFS, err)
 			return
 		}
-		defer cli.Close()
 
 		// Inspect the container to get detailed information
 		containerJSON, err := cli.ContainerInspect(ctx, containerID)
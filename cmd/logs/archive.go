@@ -0,0 +1,302 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+
+	"github.com/dwui/cmd/database"
+)
+
+// ArchivedContainer describes a container whose logs are in the archive.
+// First and Last are the timestamps of its oldest and newest archived lines.
+type ArchivedContainer struct {
+	ID    string    `json:"id"`
+	Name  string    `json:"name"`
+	Image string    `json:"image"`
+	TTY   bool      `json:"tty"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// archiveRetention is how long archived lines are kept
+var archiveRetention time.Duration
+
+// StartArchiver keeps a copy of the logs of every container carrying label
+// ("key" or "key=value") in the embedded database, so they can still be read
+// after the container is removed. Lines expire after retention.
+func StartArchiver(label string, retention time.Duration) {
+	archiveRetention = retention
+
+	tailer := &Tailer{
+		Name:    "Log archive",
+		Label:   label,
+		Backlog: true,
+		Open:    openArchive,
+	}
+	tailer.Start()
+}
+
+// openArchive resumes the archive of a container after its last line
+func openArchive(tailed TailedContainer) (time.Time, func([]Line) error, error) {
+	container, found, err := FindArchived(tailed.ID)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if !found {
+		container = ArchivedContainer{ID: tailed.ID}
+	}
+	container.Name = tailed.Name
+	container.Image = tailed.Image
+	container.TTY = tailed.TTY
+
+	sequence := 0
+	return container.Last, func(batch []Line) error {
+		return storeArchive(&container, batch, &sequence)
+	}, nil
+}
+
+func storeArchive(container *ArchivedContainer, batch []Line, sequence *int) error {
+	if database.Instance == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	return database.Instance.Update(func(txn *badger.Txn) error {
+		for _, line := range batch {
+			stamp, err := time.Parse(time.RFC3339Nano, line.Time)
+			if err != nil {
+				stamp = time.Now()
+				line.Time = stamp.UTC().Format(time.RFC3339Nano)
+			}
+
+			data, err := json.Marshal(line)
+			if err != nil {
+				return err
+			}
+			*sequence++
+			key := archiveKey(container.ID, stamp, *sequence)
+			if err := txn.SetEntry(badger.NewEntry(key, data).WithTTL(archiveRetention)); err != nil {
+				return err
+			}
+
+			if container.First.IsZero() {
+				container.First = stamp
+			}
+			container.Last = stamp
+		}
+
+		// The description lives as long as the newest line
+		data, err := json.Marshal(container)
+		if err != nil {
+			return err
+		}
+		return txn.SetEntry(badger.NewEntry(archiveMetaKey(container.ID), data).WithTTL(archiveRetention))
+	})
+}
+
+// FindArchived returns the archive description of a container
+func FindArchived(containerID string) (ArchivedContainer, bool, error) {
+	if database.Instance == nil {
+		return ArchivedContainer{}, false, fmt.Errorf("database not initialized")
+	}
+
+	var container ArchivedContainer
+	err := database.Instance.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(archiveMetaKey(containerID))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &container)
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return ArchivedContainer{}, false, nil
+	}
+	return container, err == nil, err
+}
+
+// ListArchived returns every container with archived logs, most recently
+// active first
+func ListArchived() ([]ArchivedContainer, error) {
+	if database.Instance == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	list := []ArchivedContainer{}
+	err := database.Instance.View(func(txn *badger.Txn) error {
+		prefix := archiveMetaKey("")
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: 100, Prefix: prefix})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var container ArchivedContainer
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &container)
+			})
+			if err != nil {
+				return err
+			}
+			list = append(list, container)
+		}
+		return nil
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Last.After(list[j].Last)
+	})
+	return list, err
+}
+
+// ReadArchive emits the archived lines of a container selected by options,
+// in the same way Stream does for live logs. The archive is never followed.
+func ReadArchive(container ArchivedContainer, options StreamOptions, emit func(Line) error) error {
+	if database.Instance == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	now := time.Now()
+	if options.After != "" {
+		options.Since, options.Tail = options.After, ""
+	}
+	since, hasSince := resolveTime(options.Since, now)
+	until, hasUntil := resolveTime(options.Until, now)
+	inRange := func(stamp time.Time) bool {
+		return (!hasSince || !stamp.Before(since)) && (!hasUntil || !stamp.After(until))
+	}
+
+	emit = options.filter(emit)
+	prefix := archiveLinePrefix(container.ID)
+
+	return database.Instance.View(func(txn *badger.Txn) error {
+		read := func(item *badger.Item) (Line, time.Time, error) {
+			var line Line
+			err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &line)
+			})
+			stamp, _ := time.Parse(time.RFC3339Nano, line.Time)
+			line.Container = ""
+			return line, stamp, err
+		}
+
+		// Like Docker, the tail is taken before filtering
+		if options.Tail != "" {
+			tail, _ := strconv.Atoi(options.Tail)
+
+			it := txn.NewIterator(badger.IteratorOptions{Reverse: true, Prefix: prefix})
+			defer it.Close()
+
+			seek := append(archiveLinePrefix(container.ID), 0xff)
+			if hasUntil {
+				seek = archiveKey(container.ID, until, 1<<31)
+			}
+
+			lines := []Line{}
+			for it.Seek(seek); it.Valid() && len(lines) < tail; it.Next() {
+				line, stamp, err := read(it.Item())
+				if err != nil {
+					return err
+				}
+				if hasSince && stamp.Before(since) {
+					break
+				}
+				if inRange(stamp) {
+					lines = append(lines, line)
+				}
+			}
+
+			for i := len(lines) - 1; i >= 0; i-- {
+				if err := emit(lines[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+
+		seek := prefix
+		if hasSince {
+			seek = archiveKey(container.ID, since, 0)
+		}
+		for it.Seek(seek); it.Valid(); it.Next() {
+			line, stamp, err := read(it.Item())
+			if err != nil {
+				return err
+			}
+			if hasUntil && stamp.After(until) {
+				break
+			}
+			if err := emit(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// archivedSource returns the archive of a container when the archive was
+// asked for explicitly or the container no longer exists
+func archivedSource(ctx context.Context, cli *client.Client, containerID string, options StreamOptions) (ArchivedContainer, bool) {
+	container, found, err := FindArchived(containerID)
+	if err != nil || !found {
+		return ArchivedContainer{}, false
+	}
+	if options.Archive {
+		return container, true
+	}
+
+	_, err = cli.ContainerInspect(ctx, containerID)
+	return container, errdefs.IsNotFound(err)
+}
+
+// resolveTime turns a since/until option into a time, durations counting
+// back from now
+func resolveTime(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), true
+	}
+	stamp, err := time.Parse(time.RFC3339Nano, value)
+	return stamp, err == nil
+}
+
+func archiveMetaKey(containerID string) []byte {
+	return []byte("logs:meta:" + containerID)
+}
+
+func archiveLinePrefix(containerID string) []byte {
+	return []byte("logs:line:" + containerID + ":")
+}
+
+// archiveKey zero-pads the line time so keys sort chronologically, the
+// sequence keeps lines written in the same nanosecond apart
+func archiveKey(containerID string, stamp time.Time, sequence int) []byte {
+	return append(archiveLinePrefix(containerID), fmt.Sprintf("%020d:%010d", stamp.UnixNano(), sequence)...)
+}
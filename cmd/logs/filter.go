@@ -0,0 +1,192 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Levels lists the detected log levels from least to most severe
+var Levels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// levelAliases maps the spellings found in logs to a level of Levels
+var levelAliases = map[string]string{
+	"trace":    "trace",
+	"debug":    "debug",
+	"dbg":      "debug",
+	"info":     "info",
+	"notice":   "info",
+	"warn":     "warn",
+	"warning":  "warn",
+	"error":    "error",
+	"err":      "error",
+	"fatal":    "fatal",
+	"critical": "fatal",
+	"crit":     "fatal",
+	"panic":    "fatal",
+}
+
+var (
+	// levelField matches structured levels such as level=warn or "level":"error"
+	levelField = regexp.MustCompile(`(?i)\b(?:level|lvl|severity)"?\s*[=:]\s*"?([a-z]+)`)
+
+	// levelWord matches levels written as an upper case word, such as
+	// "ERROR" or "[WARN]"
+	levelWord = regexp.MustCompile(`\b(TRACE|DEBUG|DBG|INFO|NOTICE|WARN|WARNING|ERROR|ERR|FATAL|CRITICAL|CRIT|PANIC)\b`)
+)
+
+// DetectLevel guesses the level of a log line, or returns an empty string
+// when the line does not carry one
+func DetectLevel(text string) string {
+	if match := levelField.FindStringSubmatch(text); match != nil {
+		if level, ok := levelAliases[strings.ToLower(match[1])]; ok {
+			return level
+		}
+	}
+	if match := levelWord.FindStringSubmatch(text); match != nil {
+		return levelAliases[strings.ToLower(match[1])]
+	}
+	return ""
+}
+
+// levelKeys are the JSON fields that hold the level of structured lines
+var levelKeys = []string{"level", "lvl", "severity"}
+
+// ParseFields parses lines holding a JSON object into a flat map. Nested
+// objects are flattened into dotted keys ("http.status") and arrays are kept
+// as JSON. Lines that are not JSON objects return nil.
+func ParseFields(text string) map[string]string {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+
+	var object map[string]any
+	if err := decoder.Decode(&object); err != nil || decoder.More() {
+		return nil
+	}
+
+	fields := map[string]string{}
+	flatten("", object, fields)
+	return fields
+}
+
+func flatten(prefix string, value any, fields map[string]string) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, nested := range value {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flatten(key, nested, fields)
+		}
+	case string:
+		fields[prefix] = value
+	case nil:
+		fields[prefix] = "null"
+	case json.Number:
+		fields[prefix] = value.String()
+	case bool:
+		if value {
+			fields[prefix] = "true"
+		} else {
+			fields[prefix] = "false"
+		}
+	default:
+		var encoded bytes.Buffer
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(value)
+		fields[prefix] = strings.TrimSpace(encoded.String())
+	}
+}
+
+// lineLevel returns the level of a line, read from its JSON fields when it
+// has any and guessed from the text otherwise
+func lineLevel(line Line) string {
+	for _, key := range levelKeys {
+		if level, ok := levelAliases[strings.ToLower(line.Fields[key])]; ok {
+			return level
+		}
+	}
+	return DetectLevel(line.Text)
+}
+
+// severity returns the position of level in Levels, or -1 when unknown
+func severity(level string) int {
+	return slices.Index(Levels, level)
+}
+
+// filter wraps emit so that only the lines matching the options are passed
+// on. Every line gets its JSON fields and detected level, whether they are
+// filtered on or not.
+func (o StreamOptions) filter(emit func(Line) error) func(Line) error {
+	minimum := severity(o.Level)
+
+	return func(line Line) error {
+		// Docker includes lines written at exactly Since, the resumed
+		// stream already has them
+		if !o.after.IsZero() {
+			if stamp, err := time.Parse(time.RFC3339Nano, line.Time); err == nil && !stamp.After(o.after) {
+				return nil
+			}
+		}
+
+		line.Fields = ParseFields(line.Text)
+		line.Level = lineLevel(line)
+
+		if minimum >= 0 && severity(line.Level) < minimum {
+			return nil
+		}
+		if o.include != nil && !o.include.MatchString(line.Text) {
+			return nil
+		}
+		if o.exclude != nil && o.exclude.MatchString(line.Text) {
+			return nil
+		}
+		for _, match := range o.fieldMatches {
+			if value, ok := line.Fields[match.Key]; !ok || value != match.Value {
+				return nil
+			}
+		}
+		return emit(line)
+	}
+}
+
+// FieldMatch requires a JSON field of a line to hold an exact value
+type FieldMatch struct {
+	Key   string
+	Value string
+}
+
+// ParseFieldMatch reads a "key=value" field filter
+func ParseFieldMatch(text string) (FieldMatch, bool) {
+	key, value, found := strings.Cut(text, "=")
+	key = strings.TrimSpace(key)
+	if !found || key == "" {
+		return FieldMatch{}, false
+	}
+	return FieldMatch{Key: key, Value: value}, true
+}
@@ -17,18 +17,36 @@
 package logs
 
 import (
+	"bufio"
+	"compress/gzip"
 	"embed"
+	"fmt"
 	"html/template"
+	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/dwui/cmd/containers"
+	"github.com/dwui/cmd/docker"
 	"github.com/go-chi/chi/v5"
 )
 
+// ShowPageData is shared by the single container and the merged views.
+// StreamPath is the socket the view reads from; merged views cannot be
+// downloaded and leave DownloadPath empty.
 type ShowPageData struct {
 	Content       string
 	ContainerID   string
 	ContainerName string
+	StreamPath    string
+	DownloadPath  string
+	Options       StreamOptions
+	Levels        []string
+	Query         string
+	Error         string
 }
 
 func Show(templateFS embed.FS) http.HandlerFunc {
@@ -44,9 +62,190 @@ func Show(templateFS embed.FS) http.HandlerFunc {
 			Content:       "",
 			ContainerID:   containerID,
 			ContainerName: containerName,
+			StreamPath:    "/logs/stream/" + url.PathEscape(containerID),
+			DownloadPath:  "/logs/download/" + url.PathEscape(containerID),
+		}
+		data.setOptions(req.URL.Query())
+		if data.Options.Archive {
+			data.ContainerName += " (archived)"
 		}
 
 		tmpl := template.Must(template.ParseFS(templateFS, "cmd/logs/show.gohtml"))
 		tmpl.Execute(w, data)
 	}
 }
+
+// Merged renders the logs of several containers, picked by ID or as a whole
+// compose project, in a single view
+func Merged(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		containerIDs := query["id"]
+		project := query.Get("project")
+
+		selection := url.Values{}
+		for _, containerID := range containerIDs {
+			selection.Add("id", containerID)
+		}
+
+		data := ShowPageData{}
+		switch {
+		case project != "":
+			selection.Set("project", project)
+			data.ContainerName = "Project " + project
+		case len(containerIDs) > 0:
+			data.ContainerName = fmt.Sprintf("%d containers", len(containerIDs))
+		default:
+			data.ContainerName = "No containers"
+			data.Error = "Select one or more containers first"
+		}
+		data.StreamPath = "/logs/stream?" + selection.Encode()
+		data.setOptions(query)
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/logs/show.gohtml"))
+		tmpl.Execute(w, data)
+	}
+}
+
+type ArchivePageData struct {
+	Containers []ArchiveRow
+	Error      string
+}
+
+// ArchiveRow is an archived container together with whether it still exists
+type ArchiveRow struct {
+	ArchivedContainer
+	Exists bool
+}
+
+// LogsURL opens the archived logs of the container in the logs view
+func (row ArchiveRow) LogsURL() string {
+	query := url.Values{"name": {row.Name}, "source": {"archive"}}
+	return "/logs/" + url.PathEscape(row.ID) + "?" + query.Encode()
+}
+
+// Archive lists the containers whose logs are kept in the log archive,
+// including those that were removed since
+func Archive(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		data := ArchivePageData{}
+
+		archived, err := ListArchived()
+		if err != nil {
+			data.Error = err.Error()
+		}
+		for _, container := range archived {
+			_, exists := containers.CachedByID(container.ID)
+			data.Containers = append(data.Containers, ArchiveRow{ArchivedContainer: container, Exists: exists})
+		}
+
+		docker.ReplaceURL(w, "archive", nil)
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/logs/archive.gohtml"))
+		tmpl.Execute(w, data)
+	}
+}
+
+// setOptions fills the stream options from the query, falling back to the
+// defaults when they are invalid
+func (data *ShowPageData) setOptions(query url.Values) {
+	options, err := ParseStreamOptions(query)
+	if err != nil {
+		data.Error = err.Error()
+		options, _ = ParseStreamOptions(nil)
+	}
+	data.Options = options
+	data.Levels = Levels
+	data.Query = options.Query().Encode()
+}
+
+// Download streams the log history of a container, or the requested time
+// range, as a file. Lines are decoded and written as they are read, so even
+// large logs are never held in memory.
+func Download(w http.ResponseWriter, req *http.Request) {
+	var containerID = chi.URLParam(req, "containerID")
+	query := req.URL.Query()
+
+	options, err := ParseStreamOptions(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Downloads hold the whole range unless a tail is asked for explicitly
+	if !query.Has("tail") {
+		options.Tail = ""
+	}
+
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = "text"
+	}
+	format, ok := LookupDownloadFormat(formatName)
+	if !ok {
+		http.Error(w, "Unknown download format", http.StatusBadRequest)
+		return
+	}
+
+	cli, err := docker.Client()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx := req.Context()
+
+	// read writes the selected lines to emit, from the archive for
+	// containers that are gone or from Docker otherwise
+	var name string
+	var read func(emit func(Line) error) error
+
+	if archived, ok := archivedSource(ctx, cli, containerID, options); ok {
+		name = archived.Name
+		read = func(emit func(Line) error) error {
+			return ReadArchive(archived, options, emit)
+		}
+	} else {
+		inspect, err := cli.ContainerInspect(ctx, containerID)
+		if err != nil {
+			http.Error(w, "Container inspect error", http.StatusNotFound)
+			return
+		}
+		tty := inspect.Config != nil && inspect.Config.Tty
+
+		logsOptions := options.LogsOptions()
+		logsOptions.Follow = false
+		out, err := cli.ContainerLogs(ctx, containerID, logsOptions)
+		if err != nil {
+			http.Error(w, "Container logs error", http.StatusInternalServerError)
+			return
+		}
+		defer out.Close()
+
+		name = strings.TrimPrefix(inspect.Name, "/")
+		read = func(emit func(Line) error) error {
+			return Decode(out, tty, options.filter(emit))
+		}
+	}
+
+	filename := name + "-" + time.Now().UTC().Format("20060102-150405") + format.Extension
+	contentType := format.ContentType
+
+	var dst io.Writer = w
+	if query.Get("gzip") == "1" {
+		filename += ".gz"
+		contentType = "application/gzip"
+
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		dst = gz
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	buffered := bufio.NewWriter(dst)
+	if err := read(NewEncoder(buffered, formatName, options.Timestamps)); err != nil {
+		log.Printf("Log download error for %s: %v", containers.ShortenID(containerID), err)
+	}
+	buffered.Flush()
+}
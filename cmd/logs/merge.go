@@ -0,0 +1,298 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package logs
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// projectLabel is the label Docker Compose puts on every container of a project
+	projectLabel = "com.docker.compose.project"
+
+	// maxMergedContainers bounds how many log streams a merged view opens
+	maxMergedContainers = 20
+
+	// mergeDelay is how long lines are held back so that lines of other
+	// containers written at the same time can be ordered before them
+	mergeDelay = 250 * time.Millisecond
+)
+
+// Target is a container whose logs are streamed
+type Target struct {
+	ID   string
+	Name string
+	TTY  bool
+}
+
+// ResolveTargets returns the containers selected by ID or by compose project
+func ResolveTargets(ctx context.Context, cli *client.Client, containerIDs []string, project string) ([]Target, error) {
+	if project != "" {
+		list, err := cli.ContainerList(ctx, containertypes.ListOptions{
+			All:     true,
+			Filters: filters.NewArgs(filters.Arg("label", projectLabel+"="+project)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range list {
+			containerIDs = append(containerIDs, container.ID)
+		}
+	}
+
+	if len(containerIDs) == 0 {
+		return nil, errors.New("no containers selected")
+	}
+	if len(containerIDs) > maxMergedContainers {
+		return nil, errors.New("too many containers selected")
+	}
+
+	seen := map[string]bool{}
+	targets := []Target{}
+	for _, containerID := range containerIDs {
+		inspect, err := cli.ContainerInspect(ctx, containerID)
+		if err != nil {
+			return nil, err
+		}
+		if seen[inspect.ID] {
+			continue
+		}
+		seen[inspect.ID] = true
+
+		targets = append(targets, Target{
+			ID:   inspect.ID,
+			Name: strings.TrimPrefix(inspect.Name, "/"),
+			TTY:  inspect.Config != nil && inspect.Config.Tty,
+		})
+	}
+	return targets, nil
+}
+
+// Merge streams the logs of several containers into a single sequence of
+// lines tagged with the container name, ordered by their Docker timestamp.
+// The backlog of every container is read in full and sorted first. Live
+// lines arrive independently, so each one is held for mergeDelay so that
+// lines of other containers can be sorted in between. Each container
+// resumes after its own cursor in options.AfterByContainer.
+//
+// A container whose logs cannot be read is reported by a system line, the
+// others keep streaming. Merge only fails when every container failed.
+func Merge(ctx context.Context, cli *client.Client, targets []Target, options StreamOptions, emit func(Line) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The backlog ends where following starts, so no line falls in between
+	start := time.Now()
+	backlog := options
+	if options.Follow() {
+		backlog.Until = start.Format(time.RFC3339Nano)
+	}
+
+	failed, err := mergeBacklog(ctx, cli, targets, backlog, emit)
+	if err != nil || !options.Follow() {
+		return err
+	}
+
+	live := options
+	live.After, live.after = backlog.Until, start
+	live.AfterByContainer, live.afterByContainer = nil, nil
+
+	lines := make(chan pendingLine)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := Stream(ctx, cli, target, live, func(line Line) error {
+				line.Container = target.Name
+				select {
+				case lines <- newPendingLine(line):
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+			mu.Lock()
+			failed[target.ID] = err
+			mu.Unlock()
+			select {
+			case lines <- newPendingLine(streamError(target, err)):
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	pending := &lineHeap{}
+	ticker := time.NewTicker(mergeDelay / 5)
+	defer ticker.Stop()
+
+	// flush emits the held lines in timestamp order, either those older than
+	// mergeDelay or all of them
+	flush := func(all bool) error {
+		cutoff := time.Now().Add(-mergeDelay)
+		for pending.Len() > 0 {
+			if !all && (*pending)[0].arrived.After(cutoff) {
+				break
+			}
+			if err := emit(heap.Pop(pending).(pendingLine).Line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if err := flush(true); err != nil {
+					return err
+				}
+				return allFailed(targets, failed)
+			}
+			heap.Push(pending, line)
+		case <-ticker.C:
+			if err := flush(false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// mergeBacklog reads the logs selected by options from every container,
+// then emits them in timestamp order followed by the errors of the
+// containers that could not be read. Those are returned by container ID.
+func mergeBacklog(ctx context.Context, cli *client.Client, targets []Target, options StreamOptions, emit func(Line) error) (map[string]error, error) {
+	var mu sync.Mutex
+	pending := &lineHeap{}
+	failed := map[string]error{}
+
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := Stream(ctx, cli, target, options.forContainer(target.Name), func(line Line) error {
+				line.Container = target.Name
+				mu.Lock()
+				heap.Push(pending, newPendingLine(line))
+				mu.Unlock()
+				return nil
+			})
+			if err != nil && ctx.Err() == nil {
+				mu.Lock()
+				failed[target.ID] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for pending.Len() > 0 {
+		if err := emit(heap.Pop(pending).(pendingLine).Line); err != nil {
+			return failed, err
+		}
+	}
+	for _, target := range targets {
+		if err, ok := failed[target.ID]; ok {
+			if err := emit(streamError(target, err)); err != nil {
+				return failed, err
+			}
+		}
+	}
+	return failed, allFailed(targets, failed)
+}
+
+// streamError is the system line telling the browser the logs of a
+// container could not be read
+func streamError(target Target, err error) Line {
+	return Line{Container: target.Name, Stream: "system", Text: "Log stream error: " + err.Error()}
+}
+
+// allFailed returns the errors of the containers when none of them could
+// be read
+func allFailed(targets []Target, failed map[string]error) error {
+	errs := []error{}
+	for _, target := range targets {
+		if err, ok := failed[target.ID]; ok {
+			errs = append(errs, fmt.Errorf("%s: %w", target.Name, err))
+		}
+	}
+	if len(errs) < len(targets) {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+type pendingLine struct {
+	Line
+	stamp   time.Time
+	arrived time.Time
+}
+
+func newPendingLine(line Line) pendingLine {
+	arrived := time.Now()
+	stamp, err := time.Parse(time.RFC3339Nano, line.Time)
+	if err != nil {
+		stamp = arrived
+	}
+	return pendingLine{Line: line, stamp: stamp, arrived: arrived}
+}
+
+// lineHeap orders pending lines by timestamp, then by arrival
+type lineHeap []pendingLine
+
+func (h lineHeap) Len() int { return len(h) }
+
+func (h lineHeap) Less(i, j int) bool {
+	if !h[i].stamp.Equal(h[j].stamp) {
+		return h[i].stamp.Before(h[j].stamp)
+	}
+	return h[i].arrived.Before(h[j].arrived)
+}
+
+func (h lineHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *lineHeap) Push(x any) { *h = append(*h, x.(pendingLine)) }
+
+func (h *lineHeap) Pop() any {
+	old := *h
+	line := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return line
+}
@@ -0,0 +1,426 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Line is a single log line as sent to the browser. Time is the timestamp
+// Docker recorded for the line, in RFC 3339 format with nanoseconds.
+// Container names the source of the line in merged views. Fields holds the
+// parsed content of JSON lines. Cursor is only set on the log socket, where
+// it carries the timestamp of the line even when Time is hidden, so the
+// browser can resume the stream after it.
+type Line struct {
+	Container string            `json:"container,omitempty"`
+	Stream    string            `json:"stream"`
+	Time      string            `json:"time,omitempty"`
+	Cursor    string            `json:"cursor,omitempty"`
+	Level     string            `json:"level,omitempty"`
+	Text      string            `json:"text"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// defaultTail is how many lines are shown when no time range is requested
+const defaultTail = "50"
+
+// StreamOptions selects which part of a container's log is streamed. Since
+// and Until accept RFC 3339 timestamps or durations relative to now ("15m").
+// An empty Tail means every line in the range.
+//
+// The remaining fields filter lines on the server: Stream keeps only stdout
+// or stderr, Level keeps lines detected at that level or above, and Include
+// and Exclude are regular expressions matched against the line text. Fields
+// holds "key=value" filters that JSON lines must all match. Tail is applied
+// by Docker before filtering. Archive reads the lines from the log archive
+// instead of the container.
+//
+// After resumes a stream: only lines written strictly after that RFC 3339
+// timestamp are sent, whatever Since and Tail say. Merged views resume
+// every container after its own last line instead, given by container name
+// in AfterByContainer, since lines of other containers up to that time may
+// not have been sent yet.
+type StreamOptions struct {
+	Since      string
+	Until      string
+	Tail       string
+	Timestamps bool
+	Stream     string
+	Level      string
+	Include    string
+	Exclude    string
+	Fields     []string
+	Archive    bool
+	After      string
+
+	AfterByContainer map[string]string
+
+	after            time.Time
+	afterByContainer map[string]time.Time
+	include          *regexp.Regexp
+	exclude          *regexp.Regexp
+	fieldMatches     []FieldMatch
+}
+
+// ParseStreamOptions reads the stream options from the log view query. Without
+// a time range only the last defaultTail lines are shown.
+func ParseStreamOptions(query url.Values) (StreamOptions, error) {
+	options := StreamOptions{
+		Since:      strings.TrimSpace(query.Get("since")),
+		Until:      strings.TrimSpace(query.Get("until")),
+		Tail:       strings.TrimSpace(query.Get("tail")),
+		Timestamps: query.Get("timestamps") == "1",
+		Stream:     query.Get("stream"),
+		Level:      query.Get("level"),
+		Include:    query.Get("include"),
+		Exclude:    query.Get("exclude"),
+		Archive:    query.Get("source") == "archive",
+	}
+
+	// Merged views send one "name=timestamp" cursor per container, container
+	// names cannot hold an equal sign
+	for _, after := range query["after"] {
+		if name, stamp, ok := strings.Cut(after, "="); ok {
+			if options.AfterByContainer == nil {
+				options.AfterByContainer = map[string]string{}
+			}
+			options.AfterByContainer[name] = stamp
+		} else {
+			options.After = after
+		}
+	}
+
+	for _, field := range query["field"] {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		match, ok := ParseFieldMatch(field)
+		if !ok {
+			return options, errors.New("invalid field filter: must be key=value")
+		}
+		options.Fields = append(options.Fields, field)
+		options.fieldMatches = append(options.fieldMatches, match)
+	}
+
+	if options.Stream != "" && options.Stream != "stdout" && options.Stream != "stderr" {
+		return options, errors.New("invalid stream: must be stdout or stderr")
+	}
+	if options.Level != "" && severity(options.Level) < 0 {
+		return options, errors.New("invalid level: must be one of " + strings.Join(Levels, ", "))
+	}
+
+	var err error
+	if options.Include != "" {
+		if options.include, err = regexp.Compile(options.Include); err != nil {
+			return options, errors.New("invalid include pattern: " + err.Error())
+		}
+	}
+	if options.Exclude != "" {
+		if options.exclude, err = regexp.Compile(options.Exclude); err != nil {
+			return options, errors.New("invalid exclude pattern: " + err.Error())
+		}
+	}
+
+	if err := validateTime(options.Since); err != nil {
+		return options, errors.New("invalid since: " + err.Error())
+	}
+	if err := validateTime(options.Until); err != nil {
+		return options, errors.New("invalid until: " + err.Error())
+	}
+
+	if options.After != "" {
+		if options.after, err = time.Parse(time.RFC3339Nano, options.After); err != nil {
+			return options, errors.New("invalid after: " + err.Error())
+		}
+	}
+	for name, after := range options.AfterByContainer {
+		stamp, err := time.Parse(time.RFC3339Nano, after)
+		if err != nil {
+			return options, errors.New("invalid after: " + err.Error())
+		}
+		if options.afterByContainer == nil {
+			options.afterByContainer = map[string]time.Time{}
+		}
+		options.afterByContainer[name] = stamp
+	}
+
+	switch {
+	case options.Tail == "" && !query.Has("tail") && options.Since == "" && options.Until == "":
+		options.Tail = defaultTail
+	case options.Tail == "all":
+		options.Tail = ""
+	case options.Tail != "":
+		if n, err := strconv.Atoi(options.Tail); err != nil || n < 0 {
+			return options, errors.New("invalid tail: must be a positive number or all")
+		}
+	}
+
+	return options, nil
+}
+
+func validateTime(value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := time.ParseDuration(value); err == nil {
+		return nil
+	}
+	_, err := time.Parse(time.RFC3339Nano, value)
+	return err
+}
+
+// Follow reports whether the stream keeps waiting for new lines. Ranges with
+// an end are read once.
+func (o StreamOptions) Follow() bool {
+	return o.Until == ""
+}
+
+// forContainer returns the options of one container of a merged view, which
+// resumes after its own cursor when it has one
+func (o StreamOptions) forContainer(name string) StreamOptions {
+	if after, ok := o.afterByContainer[name]; ok {
+		o.After, o.after = o.AfterByContainer[name], after
+	}
+	return o
+}
+
+// LogsOptions converts the stream options into a single Docker logs request.
+// Tail and Follow are served by the same call, so no line written between
+// the backlog and the live part can be lost. Timestamps are always requested
+// because they are needed to order and resume lines.
+func (o StreamOptions) LogsOptions() containertypes.LogsOptions {
+	since, tail := o.Since, o.Tail
+	if o.After != "" {
+		since, tail = o.After, ""
+	}
+	if tail == "" {
+		tail = "all"
+	}
+
+	return containertypes.LogsOptions{
+		ShowStdout: o.Stream != "stderr",
+		ShowStderr: o.Stream != "stdout",
+		Follow:     o.Follow(),
+		Timestamps: true,
+		Since:      since,
+		Until:      o.Until,
+		Tail:       tail,
+	}
+}
+
+// Query encodes the options back into URL query values
+func (o StreamOptions) Query() url.Values {
+	query := url.Values{}
+	if o.Since != "" {
+		query.Set("since", o.Since)
+	}
+	if o.Until != "" {
+		query.Set("until", o.Until)
+	}
+	if o.Tail == "" {
+		query.Set("tail", "all")
+	} else {
+		query.Set("tail", o.Tail)
+	}
+	if o.Timestamps {
+		query.Set("timestamps", "1")
+	}
+	if o.Stream != "" {
+		query.Set("stream", o.Stream)
+	}
+	if o.Level != "" {
+		query.Set("level", o.Level)
+	}
+	if o.Include != "" {
+		query.Set("include", o.Include)
+	}
+	if o.Exclude != "" {
+		query.Set("exclude", o.Exclude)
+	}
+	for _, field := range o.Fields {
+		query.Add("field", field)
+	}
+	if o.Archive {
+		query.Set("source", "archive")
+	}
+	return query
+}
+
+// splitTimestamp separates the timestamp Docker prefixes to each line when
+// Timestamps is requested
+func splitTimestamp(line string) (string, string) {
+	stamp, text, found := strings.Cut(line, " ")
+	if !found {
+		stamp, text = line, ""
+	}
+	if _, err := time.Parse(time.RFC3339Nano, stamp); err != nil {
+		return "", line
+	}
+	return stamp, text
+}
+
+// maxLineSize bounds how much of an unterminated line is buffered before it
+// is emitted anyway
+const maxLineSize = 1024 * 1024
+
+// Stream reads the logs of a single container. A single request serves both
+// the backlog and the live lines, so nothing written in between is lost.
+func Stream(ctx context.Context, cli *client.Client, target Target, options StreamOptions, emit func(Line) error) error {
+	out, err := cli.ContainerLogs(ctx, target.ID, options.LogsOptions())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return Decode(out, target.TTY, options.filter(emit))
+}
+
+// Decode reads a container log stream requested with timestamps and calls
+// emit for every line. Streams of containers without a TTY are multiplexed,
+// each frame carrying a header that tells stdout and stderr apart. TTY
+// containers write raw bytes and everything is reported as stdout.
+func Decode(r io.Reader, tty bool, emit func(Line) error) error {
+	if tty {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+		for scanner.Scan() {
+			stamp, text := splitTimestamp(scanner.Text())
+			if err := emit(Line{Stream: "stdout", Time: stamp, Text: strings.TrimSuffix(text, "\r")}); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	}
+
+	stdout := &lineWriter{stream: "stdout", emit: emit}
+	stderr := &lineWriter{stream: "stderr", emit: emit}
+	if _, err := stdcopy.StdCopy(stdout, stderr, r); err != nil {
+		return err
+	}
+
+	if err := stdout.Flush(); err != nil {
+		return err
+	}
+	return stderr.Flush()
+}
+
+// lineWriter splits the bytes written to it into lines. Each write holds one
+// frame, starting with the timestamp of the log entry. Long entries are split
+// by Docker into several frames, so incomplete lines are kept until the rest
+// arrives and only the timestamp of their first frame is used.
+type lineWriter struct {
+	stream string
+	emit   func(Line) error
+	buffer []byte
+	stamp  string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	stamp, text := splitTimestamp(string(p))
+	if stamp != "" && len(w.buffer) == 0 {
+		w.stamp = stamp
+	}
+	w.buffer = append(w.buffer, text...)
+
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		line := w.buffer[:i]
+		w.buffer = w.buffer[i+1:]
+		if err := w.send(line); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(w.buffer) >= maxLineSize {
+		if err := w.Flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush emits whatever is left of an unterminated line
+func (w *lineWriter) Flush() error {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	line := w.buffer
+	w.buffer = nil
+	return w.send(line)
+}
+
+func (w *lineWriter) send(line []byte) error {
+	return w.emit(Line{Stream: w.stream, Time: w.stamp, Text: strings.TrimSuffix(string(line), "\r")})
+}
+
+// DownloadFormat describes a file format logs can be downloaded as
+type DownloadFormat struct {
+	Extension   string
+	ContentType string
+}
+
+var downloadFormats = map[string]DownloadFormat{
+	"text":   {Extension: ".log", ContentType: "text/plain; charset=utf-8"},
+	"ndjson": {Extension: ".ndjson", ContentType: "application/x-ndjson"},
+}
+
+// LookupDownloadFormat returns the download format registered under name
+func LookupDownloadFormat(name string) (DownloadFormat, bool) {
+	format, ok := downloadFormats[name]
+	return format, ok
+}
+
+// NewEncoder returns an emit function for Decode that writes lines to w in
+// the given format. Plain text only keeps the timestamps when asked to,
+// NDJSON always carries the timestamp and stream of every line.
+func NewEncoder(w io.Writer, format string, timestamps bool) func(Line) error {
+	if format == "ndjson" {
+		encoder := json.NewEncoder(w)
+		return func(line Line) error {
+			return encoder.Encode(line)
+		}
+	}
+
+	return func(line Line) error {
+		if timestamps && line.Time != "" {
+			if _, err := io.WriteString(w, line.Time+" "); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, line.Text+"\n")
+		return err
+	}
+}
@@ -17,28 +17,49 @@
 package logs
 
 import (
-	"bufio"
 	"context"
-	"log"
 	"net/http"
+	"time"
 
-	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/go-chi/chi/v5"
-
-	"github.com/docker/docker/client"
 	"github.com/gorilla/websocket"
+
+	"github.com/dwui/cmd/docker"
+)
+
+const (
+	// pingPeriod is how often the socket is pinged to keep proxies from
+	// closing it while the logs are quiet
+	pingPeriod = 30 * time.Second
+
+	// pongWait is how long the browser may stay silent before the socket is
+	// considered dead. Browsers answer pings on their own.
+	pongWait = 2 * pingPeriod
 )
 
+// Socket streams the logs of a container. Without a container in the path
+// it streams every container given by id or compose project in the query,
+// merged into one stream ordered by timestamp.
+//
+// Every line carries its timestamp as a cursor. A browser that lost the
+// socket reconnects with after set to the last cursor it received and the
+// stream continues with the next line. Merged streams take one
+// "name=cursor" after per container.
 func Socket(w http.ResponseWriter, r *http.Request) {
 	var containerID = chi.URLParam(r, "containerID")
-	ctx := context.Background()
+	query := r.URL.Query()
+
+	options, err := ParseStreamOptions(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := docker.Client()
 	if err != nil {
-		http.Error(w, "Docker client error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		return
 	}
-	defer cli.Close()
 
 	var upgrader = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -52,65 +73,97 @@ func Socket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer wsConn.Close()
 
-	// Send some initial logs first, then follow new ones
-	initialOptions := containertypes.LogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Follow:     false,
-		Timestamps: false,
-		Tail:       "50", // Send last 50 lines initially for better context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keepAlive(wsConn, cancel)
+
+	send := func(line Line) error {
+		line.Cursor = line.Time
+		if !options.Timestamps {
+			line.Time = ""
+		}
+		return wsConn.WriteJSON(line)
 	}
 
-	initialOut, err := cli.ContainerLogs(ctx, containerID, initialOptions)
-	if err == nil {
-		// Send initial logs
-		scanner := bufio.NewScanner(initialOut)
-		for scanner.Scan() {
-			line := scanner.Text()
-			// Clean the line using the same logic as the service
-			if len(line) > 8 && line[0] <= 2 {
-				line = line[8:]
-			}
-			if line != "" {
-				err = wsConn.WriteMessage(websocket.TextMessage, []byte(line))
-				if err != nil {
-					initialOut.Close()
-					return
-				}
+	// Containers that are gone can still be read from the log archive
+	if containerID != "" {
+		if archived, ok := archivedSource(ctx, cli, containerID, options); ok {
+			if err := ReadArchive(archived, options, send); err != nil {
+				wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Log archive error"))
+				return
 			}
+			wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "End of archived logs"))
+			return
 		}
-		initialOut.Close()
 	}
 
-	// Now start following new logs
-	followOptions := containertypes.LogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Follow:     true,
-		Timestamps: false,
-		Since:      "1s", // Only get logs from the last 1 second onwards
+	containerIDs := query["id"]
+	if containerID != "" {
+		containerIDs = []string{containerID}
+	}
+	// A selection that cannot be resolved will not get better by retrying,
+	// which the browser is told by the policy violation code
+	targets, err := ResolveTargets(ctx, cli, containerIDs, query.Get("project"))
+	if err != nil {
+		wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()))
+		return
 	}
 
-	followOut, err := cli.ContainerLogs(ctx, containerID, followOptions)
+	// Read from container logs line by line and write to websocket, until
+	// either side goes away
+	if containerID != "" {
+		err = Stream(ctx, cli, targets[0], options, send)
+	} else {
+		err = Merge(ctx, cli, targets, options, send)
+	}
 	if err != nil {
-		log.Println("Error following logs:", err)
+		wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Log stream error"))
 		return
 	}
-	defer followOut.Close()
-
-	// Read from container logs line by line and write to websocket
-	scanner := bufio.NewScanner(followOut)
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Clean the line using the same logic as the service
-		if len(line) > 8 && line[0] <= 2 {
-			line = line[8:]
+
+	// Ranges with an end are complete once the stream is drained. Followed
+	// streams also end when a container stops or restarts, the browser
+	// reconnects and resumes after its cursor then.
+	if !options.Follow() {
+		wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "End of logs"))
+		return
+	}
+	wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "Log stream ended"))
+}
+
+// keepAlive pings the socket every pingPeriod and reads from it so that
+// pongs and the close of the browser are noticed. cancel is called once the
+// browser is gone or stopped answering.
+func keepAlive(wsConn *websocket.Conn, cancel context.CancelFunc) {
+	wsConn.SetReadDeadline(time.Now().Add(pongWait))
+	wsConn.SetPongHandler(func(string) error {
+		return wsConn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer cancel()
+		for {
+			if _, _, err := wsConn.ReadMessage(); err != nil {
+				return
+			}
 		}
-		if line != "" {
-			err = wsConn.WriteMessage(websocket.TextMessage, []byte(line))
-			if err != nil {
+	}()
+
+	go func() {
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := wsConn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingPeriod)); err != nil {
+					cancel()
+					return
+				}
+			case <-done:
 				return
 			}
 		}
-	}
+	}()
 }
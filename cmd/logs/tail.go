@@ -0,0 +1,212 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package logs
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/dwui/cmd/docker"
+	"github.com/dwui/cmd/events"
+)
+
+const (
+	// tailFlushInterval bounds how long followed lines wait in memory
+	tailFlushInterval = time.Second
+
+	// tailBatchSize is how many lines are handed over at once
+	tailBatchSize = 500
+)
+
+// TailedContainer describes a container followed by a Tailer
+type TailedContainer struct {
+	ID     string
+	Name   string
+	Image  string
+	TTY    bool
+	Labels map[string]string
+}
+
+// Tailer follows the logs of every running container carrying Label and
+// hands their lines over in batches, flushed at least every second so that
+// a quiet container is stored promptly. Only one tail per container runs at
+// a time, and each tail resumes after the newest line stored by the
+// previous one, so nothing is stored twice. JSON fields are not kept, they
+// are parsed again when the lines are read.
+type Tailer struct {
+	// Name prefixes the log messages of the tailer
+	Name string
+
+	// Label ("key" or "key=value") selects the followed containers, all
+	// containers are followed when empty
+	Label string
+
+	// Backlog reads the whole log of a container seen for the first time,
+	// otherwise it is followed from its next line
+	Backlog bool
+
+	// Open prepares storing the lines of a container. It returns the
+	// timestamp of the newest line already stored, zero when there is none,
+	// and the function that stores a batch. Store is called from a single
+	// goroutine per tail and must not keep the batch, which is reused.
+	Open func(container TailedContainer) (resume time.Time, store func(batch []Line) error, err error)
+
+	mu      sync.Mutex
+	tailing map[string]bool
+}
+
+// Start follows the running containers on every (re)connection of the
+// events stream and the containers that start afterwards
+func (t *Tailer) Start() {
+	t.tailing = map[string]bool{}
+
+	events.OnConnect(func() {
+		cli, err := docker.Client()
+		if err != nil {
+			return
+		}
+		options := containertypes.ListOptions{}
+		if t.Label != "" {
+			options.Filters = filters.NewArgs(filters.Arg("label", t.Label))
+		}
+		list, err := cli.ContainerList(context.Background(), options)
+		if err != nil {
+			log.Printf("%s list error: %v", t.Name, err)
+			return
+		}
+		for _, container := range list {
+			go t.follow(container.ID)
+		}
+	})
+
+	events.OnEvent(func(event events.Event) {
+		if event.Type == "container" && event.Action == "start" && docker.HasLabel(event.Attributes, t.Label) {
+			go t.follow(event.ID)
+		}
+	})
+}
+
+// follow reads the logs of a container until it stops
+func (t *Tailer) follow(containerID string) {
+	t.mu.Lock()
+	if t.tailing[containerID] {
+		t.mu.Unlock()
+		return
+	}
+	t.tailing[containerID] = true
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.tailing, containerID)
+		t.mu.Unlock()
+	}()
+
+	cli, err := docker.Client()
+	if err != nil {
+		return
+	}
+
+	ctx := context.Background()
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return
+	}
+
+	container := TailedContainer{
+		ID:   inspect.ID,
+		Name: strings.TrimPrefix(inspect.Name, "/"),
+	}
+	if inspect.Config != nil {
+		container.Image = inspect.Config.Image
+		container.TTY = inspect.Config.Tty
+		container.Labels = inspect.Config.Labels
+	}
+
+	resume, store, err := t.Open(container)
+	if err != nil {
+		log.Printf("%s error for %s: %v", t.Name, container.Name, err)
+		return
+	}
+
+	options := StreamOptions{Timestamps: true}
+	switch {
+	case !resume.IsZero():
+		options.After = resume.Format(time.RFC3339Nano)
+		options.after = resume
+	case !t.Backlog:
+		options.Tail = "0"
+	}
+
+	lines := make(chan Line, tailBatchSize)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t.write(container, lines, store)
+	}()
+
+	target := Target{ID: container.ID, Name: container.Name, TTY: container.TTY}
+	err = Stream(ctx, cli, target, options, func(line Line) error {
+		line.Fields = nil
+		lines <- line
+		return nil
+	})
+	if err != nil {
+		log.Printf("%s stream error for %s: %v", t.Name, container.Name, err)
+	}
+	close(lines)
+	<-done
+}
+
+// write hands the lines over in batches of up to tailBatchSize
+func (t *Tailer) write(container TailedContainer, lines <-chan Line, store func([]Line) error) {
+	ticker := time.NewTicker(tailFlushInterval)
+	defer ticker.Stop()
+
+	batch := []Line{}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := store(batch); err != nil {
+			log.Printf("%s write error for %s: %v", t.Name, container.Name, err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, line)
+			if len(batch) >= tailBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package processes
+
+import (
+	"context"
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/dwui/cmd/containers"
+	"github.com/dwui/cmd/docker"
+)
+
+type ShowPageData struct {
+	TablePageData
+	Signals []string
+}
+
+type TablePageData struct {
+	ContainerID   string
+	ContainerName string
+	Table         ProcessTable
+	Error         string
+}
+
+// TableURL returns the URL of the process table sorted by the given column
+func (data TablePageData) TableURL(sortBy string, desc bool) string {
+	query := url.Values{}
+	query.Set("name", data.ContainerName)
+	if sortBy != "" {
+		query.Set("sort", sortBy)
+	}
+	if desc {
+		query.Set("desc", "1")
+	}
+	return "/processes/table/" + data.ContainerID + "?" + query.Encode()
+}
+
+// SortURL returns the URL that sorts the table by title, toggling the
+// direction when the table is already sorted by it
+func (data TablePageData) SortURL(title string) string {
+	return data.TableURL(title, data.Table.Sort == title && !data.Table.Desc)
+}
+
+type SignalPageData struct {
+	Message string
+	Error   string
+}
+
+func Show(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		data := ShowPageData{
+			TablePageData: loadTable(req),
+			Signals:       Signals,
+		}
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/processes/show.gohtml"))
+		tmpl.Execute(w, data)
+	}
+}
+
+// Table renders only the process table, used for sorting and auto-refresh
+func Table(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/processes/show.gohtml"))
+		tmpl.ExecuteTemplate(w, "table", loadTable(req))
+	}
+}
+
+func SendSignal(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/processes/show.gohtml"))
+
+		pid, err := strconv.Atoi(req.FormValue("pid"))
+		if err != nil || pid <= 0 {
+			tmpl.ExecuteTemplate(w, "signal", SignalPageData{Error: "Select a process first"})
+			return
+		}
+
+		ctx := context.Background()
+		cli, err := docker.Client()
+		if err != nil {
+			tmpl.ExecuteTemplate(w, "signal", SignalPageData{Error: err.Error()})
+			return
+		}
+
+		data := SignalPageData{}
+		data.Message, err = Signal(ctx, cli, containerID, pid, req.FormValue("signal"))
+		if err != nil {
+			log.Printf("signal to %s pid %d error: %v", containers.ShortenID(containerID), pid, err)
+			data.Error = err.Error()
+		}
+
+		tmpl.ExecuteTemplate(w, "signal", data)
+	}
+}
+
+func loadTable(req *http.Request) TablePageData {
+	var containerID = chi.URLParam(req, "containerID")
+	var containerName = req.URL.Query().Get("name")
+
+	if containerName == "" {
+		containerName = containers.ShortenID(containerID)
+	}
+
+	data := TablePageData{
+		ContainerID:   containerID,
+		ContainerName: containerName,
+	}
+
+	ctx := context.Background()
+	cli, err := docker.Client()
+	if err != nil {
+		data.Error = err.Error()
+		return data
+	}
+
+	data.Table, err = List(ctx, cli, containerID, req.URL.Query().Get("sort"), req.URL.Query().Get("desc") == "1")
+	if err != nil {
+		data.Error = err.Error()
+	}
+
+	return data
+}
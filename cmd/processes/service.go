@@ -0,0 +1,222 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package processes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Signals lists the signals that can be sent to a process from the UI
+var Signals = []string{"TERM", "INT", "HUP", "QUIT", "KILL", "USR1", "USR2", "STOP", "CONT"}
+
+// ProcessTable is the `docker top` output for a container, sorted by one column
+type ProcessTable struct {
+	Titles []string
+	Rows   [][]string
+	Sort   string
+	Desc   bool
+}
+
+// List returns the processes of a container sorted by the sortBy column.
+// Columns whose values are all numeric sort numerically.
+func List(ctx context.Context, cli *client.Client, containerID string, sortBy string, desc bool) (ProcessTable, error) {
+	top, err := cli.ContainerTop(ctx, containerID, nil)
+	if err != nil {
+		return ProcessTable{}, err
+	}
+
+	table := ProcessTable{
+		Titles: top.Titles,
+		Rows:   top.Processes,
+		Sort:   sortBy,
+		Desc:   desc,
+	}
+
+	column := slices.Index(table.Titles, sortBy)
+	if column < 0 {
+		table.Sort = ""
+		return table, nil
+	}
+
+	slices.SortStableFunc(table.Rows, func(a, b []string) int {
+		result := compare(a[column], b[column])
+		if desc {
+			return -result
+		}
+		return result
+	})
+
+	return table, nil
+}
+
+func compare(a string, b string) int {
+	numberA, errA := strconv.ParseFloat(a, 64)
+	numberB, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case numberA < numberB:
+			return -1
+		case numberA > numberB:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// Signal sends signal to a process through a one-off `kill` exec inside the
+// container. hostPID is the PID reported by `docker top`, which lives in the
+// host PID namespace and is translated to the container namespace first.
+// Only processes currently listed by `docker top` for the container can be
+// signalled, so an arbitrary host PID cannot reach another process.
+func Signal(ctx context.Context, cli *client.Client, containerID string, hostPID int, signal string) (string, error) {
+	if !slices.Contains(Signals, signal) {
+		return "", fmt.Errorf("unsupported signal %q", signal)
+	}
+
+	running, err := hasProcess(ctx, cli, containerID, hostPID)
+	if err != nil {
+		return "", err
+	}
+	if !running {
+		return "", fmt.Errorf("process %d is not running in this container", hostPID)
+	}
+
+	pid, err := containerPID(hostPID)
+	if err != nil {
+		return "", err
+	}
+
+	execResp, err := cli.ContainerExecCreate(ctx, containerID, containertypes.ExecOptions{
+		Cmd:          []string{"kill", "-" + signal, strconv.Itoa(pid)},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hijackResp, err := cli.ContainerExecAttach(ctx, execResp.ID, containertypes.ExecStartOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer hijackResp.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, hijackResp.Reader); err != nil {
+		return "", err
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return "", err
+	}
+	if inspect.ExitCode != 0 {
+		return "", fmt.Errorf("kill exited with code %d: %s", inspect.ExitCode, strings.TrimSpace(output.String()))
+	}
+
+	return fmt.Sprintf("Sent SIG%s to PID %d", signal, pid), nil
+}
+
+// hasProcess reports whether hostPID is in the `docker top` output of the
+// container
+func hasProcess(ctx context.Context, cli *client.Client, containerID string, hostPID int) (bool, error) {
+	top, err := cli.ContainerTop(ctx, containerID, nil)
+	if err != nil {
+		return false, err
+	}
+
+	table := ProcessTable{Titles: top.Titles, Rows: top.Processes}
+	if !slices.Contains(table.Titles, "PID") {
+		return false, errors.New("the process list has no PID column")
+	}
+	return slices.ContainsFunc(table.Rows, func(row []string) bool {
+		return table.PID(row) == strconv.Itoa(hostPID)
+	}), nil
+}
+
+// SignalHost sends signal to a process of the container straight from the
+// host, for containers without a `kill` binary. It only works when dwui
+// shares the host PID namespace, where hostPID is the process itself, and
+// the process must be in the cgroup of the container. The same `docker top`
+// check as Signal applies.
+func SignalHost(ctx context.Context, cli *client.Client, containerID string, hostPID int, signal string) error {
+	if !slices.Contains(Signals, signal) {
+		return fmt.Errorf("unsupported signal %q", signal)
+	}
+
+	running, err := hasProcess(ctx, cli, containerID, hostPID)
+	if err != nil {
+		return err
+	}
+	if !running {
+		return fmt.Errorf("process %d is not running in this container", hostPID)
+	}
+
+	// In another PID namespace the same number may be an unrelated process,
+	// the cgroup of the process tells whether it belongs to the container
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	cgroup, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", hostPID))
+	if err != nil || !strings.Contains(string(cgroup), inspect.ID) {
+		return errors.New("cannot reach the process, dwui must share the host PID namespace to signal it from the host")
+	}
+	return killHostPID(hostPID, signal)
+}
+
+// containerPID maps a host PID to the PID seen inside the container using the
+// NSpid line of /proc/<pid>/status. The last entry is the innermost namespace.
+func containerPID(hostPID int) (int, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/status", hostPID))
+	if err != nil {
+		return 0, errors.New("cannot resolve the container PID, dwui must run on the Docker host to send signals")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 1 && fields[0] == "NSpid:" {
+			return strconv.Atoi(fields[len(fields)-1])
+		}
+	}
+
+	return 0, fmt.Errorf("no namespace PID found for process %d", hostPID)
+}
+
+// PID returns the value of the PID column of a row, if the table has one
+func (t ProcessTable) PID(row []string) string {
+	column := slices.Index(t.Titles, "PID")
+	if column < 0 || column >= len(row) {
+		return ""
+	}
+	return row[column]
+}
@@ -18,6 +18,7 @@ package session
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -73,6 +74,13 @@ func Clear(w http.ResponseWriter, sessionToken string) {
 	clearCookie(w)
 }
 
+// ID names a sign-in session in records kept after it, without revealing
+// the token that grants access
+func ID(sessionToken string) string {
+	sum := sha256.Sum256([]byte(sessionToken))
+	return hex.EncodeToString(sum[:6])
+}
+
 func setCookie(w http.ResponseWriter, sessionToken string) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     SessionCookieName,
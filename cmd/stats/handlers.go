@@ -0,0 +1,76 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package stats
+
+import (
+	"embed"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dwui/cmd/containers"
+	"github.com/go-chi/chi/v5"
+)
+
+type ShowPageData struct {
+	ContainerID   string
+	ContainerName string
+	Ranges        []string
+}
+
+func Show(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+		var containerName = req.URL.Query().Get("name")
+
+		if containerName == "" {
+			containerName = containers.ShortenID(containerID)
+		}
+
+		data := ShowPageData{
+			ContainerID:   containerID,
+			ContainerName: containerName,
+			Ranges:        []string{"1h", "6h", "24h", "7d"},
+		}
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/stats/show.gohtml"))
+		tmpl.Execute(w, data)
+	}
+}
+
+// History returns the stored rollups of a container as JSON. The range query
+// parameter picks both the time window and the rollup resolution.
+func History(w http.ResponseWriter, req *http.Request) {
+	var containerID = chi.URLParam(req, "containerID")
+
+	historyRange, ok := Ranges[req.URL.Query().Get("range")]
+	if !ok {
+		historyRange = Ranges["1h"]
+	}
+
+	points, err := LoadHistory(containerID, historyRange.Resolution, time.Now().Add(-historyRange.Duration))
+	if err != nil {
+		log.Println("Error loading stats history:", err)
+		http.Error(w, "Stats history error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(points)
+}
@@ -0,0 +1,248 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+
+	"github.com/dwui/cmd/database"
+	"github.com/dwui/cmd/docker"
+)
+
+// Resolution is a rollup bucket size together with how long its points are kept
+type Resolution struct {
+	Name   string
+	Bucket time.Duration
+	TTL    time.Duration
+}
+
+var Resolutions = []Resolution{
+	{Name: "1m", Bucket: time.Minute, TTL: 24 * time.Hour},
+	{Name: "5m", Bucket: 5 * time.Minute, TTL: 7 * 24 * time.Hour},
+	{Name: "1h", Bucket: time.Hour, TTL: 30 * 24 * time.Hour},
+}
+
+// Ranges maps the history ranges offered by the stats view to the rollup
+// resolution used to answer them
+var Ranges = map[string]struct {
+	Duration   time.Duration
+	Resolution string
+}{
+	"1h":  {Duration: time.Hour, Resolution: "1m"},
+	"6h":  {Duration: 6 * time.Hour, Resolution: "1m"},
+	"24h": {Duration: 24 * time.Hour, Resolution: "5m"},
+	"7d":  {Duration: 7 * 24 * time.Hour, Resolution: "1h"},
+}
+
+// samplerWorkers bounds how many containers are sampled concurrently
+const samplerWorkers = 4
+
+// Point is the average of all samples that fell into one rollup bucket
+type Point struct {
+	Time           time.Time `json:"time"`
+	Count          int       `json:"count"`
+	CPUPercent     float64   `json:"cpuPercent"`
+	MemoryUsage    float64   `json:"memoryUsage"`
+	MemoryLimit    uint64    `json:"memoryLimit"`
+	NetworkRxRate  float64   `json:"networkRxRate"`
+	NetworkTxRate  float64   `json:"networkTxRate"`
+	BlockReadRate  float64   `json:"blockReadRate"`
+	BlockWriteRate float64   `json:"blockWriteRate"`
+}
+
+func (p *Point) add(sample Sample) {
+	p.Count++
+	n := float64(p.Count)
+	p.CPUPercent += (sample.CPUPercent - p.CPUPercent) / n
+	p.MemoryUsage += (float64(sample.MemoryUsage) - p.MemoryUsage) / n
+	p.MemoryLimit = sample.MemoryLimit
+	p.NetworkRxRate += (sample.NetworkRxRate - p.NetworkRxRate) / n
+	p.NetworkTxRate += (sample.NetworkTxRate - p.NetworkTxRate) / n
+	p.BlockReadRate += (sample.BlockReadRate - p.BlockReadRate) / n
+	p.BlockWriteRate += (sample.BlockWriteRate - p.BlockWriteRate) / n
+}
+
+// StartSampler samples every running container on the given interval and
+// stores the results as rollups in the embedded database. It runs until the
+// process exits.
+func StartSampler(interval time.Duration) {
+	go func() {
+		previous := map[string]*containertypes.StatsResponse{}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			// Skip rounds while the daemon is down, the rates restart from
+			// scratch once it is back
+			cli, err := docker.Client()
+			if err != nil {
+				previous = map[string]*containertypes.StatsResponse{}
+				continue
+			}
+			previous = sampleAll(cli, previous)
+		}
+	}()
+}
+
+// sampleAll records one sample for each running container. It returns the raw
+// frames so the next round can compute network and block I/O rates.
+func sampleAll(cli *client.Client, previous map[string]*containertypes.StatsResponse) map[string]*containertypes.StatsResponse {
+	ctx := context.Background()
+	list, err := cli.ContainerList(ctx, containertypes.ListOptions{})
+	if err != nil {
+		log.Println("Stats sampler list error:", err)
+		return previous
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	current := map[string]*containertypes.StatsResponse{}
+	jobs := make(chan string)
+
+	for range min(samplerWorkers, len(list)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for containerID := range jobs {
+				raw, err := readOnce(ctx, cli, containerID)
+				if err != nil {
+					log.Printf("Stats sampler error for %s: %v", containerID, err)
+					continue
+				}
+
+				mu.Lock()
+				current[containerID] = raw
+				sample := NewSample(raw, previous[containerID])
+				mu.Unlock()
+
+				if err := record(containerID, sample); err != nil {
+					log.Printf("Stats sampler store error for %s: %v", containerID, err)
+				}
+			}
+		}()
+	}
+
+	for _, container := range list {
+		jobs <- container.ID
+	}
+	close(jobs)
+	wg.Wait()
+
+	return current
+}
+
+// readOnce reads a single stats frame. The daemon waits for a second CPU
+// reading before answering, so the CPU percentage is meaningful.
+func readOnce(ctx context.Context, cli *client.Client, containerID string) (*containertypes.StatsResponse, error) {
+	statsResp, err := cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer statsResp.Body.Close()
+
+	var raw containertypes.StatsResponse
+	if err := json.NewDecoder(statsResp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
+
+// record folds a sample into the bucket of every resolution
+func record(containerID string, sample Sample) error {
+	if database.Instance == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	return database.Instance.Update(func(txn *badger.Txn) error {
+		for _, resolution := range Resolutions {
+			bucket := sample.Time.Truncate(resolution.Bucket)
+			key := historyKey(resolution.Name, containerID, bucket)
+
+			point := Point{Time: bucket}
+			item, err := txn.Get(key)
+			if err == nil {
+				err = item.Value(func(val []byte) error {
+					return json.Unmarshal(val, &point)
+				})
+			}
+			if err != nil && err != badger.ErrKeyNotFound {
+				return err
+			}
+
+			point.add(sample)
+			data, err := json.Marshal(point)
+			if err != nil {
+				return err
+			}
+
+			// The TTL counts from the bucket start so every point expires on time
+			ttl := time.Until(bucket.Add(resolution.TTL))
+			if err := txn.SetEntry(badger.NewEntry(key, data).WithTTL(ttl)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LoadHistory returns the rollup points of a container newer than since
+func LoadHistory(containerID string, resolution string, since time.Time) ([]Point, error) {
+	if database.Instance == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	points := []Point{}
+	prefix := historyPrefix(resolution, containerID)
+	err := database.Instance.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(historyKey(resolution, containerID, since.Truncate(time.Minute))); it.ValidForPrefix(prefix); it.Next() {
+			var point Point
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &point)
+			})
+			if err != nil {
+				return err
+			}
+			if !point.Time.Before(since) {
+				points = append(points, point)
+			}
+		}
+		return nil
+	})
+
+	return points, err
+}
+
+func historyPrefix(resolution string, containerID string) []byte {
+	return []byte(strings.Join([]string{"stats", resolution, containerID, ""}, ":"))
+}
+
+// historyKey zero-pads the bucket time so keys sort chronologically
+func historyKey(resolution string, containerID string, bucket time.Time) []byte {
+	return append(historyPrefix(resolution, containerID), fmt.Sprintf("%020d", bucket.Unix())...)
+}
@@ -0,0 +1,140 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package stats
+
+import (
+	"strings"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+)
+
+// Sample is a point-in-time view of a container's resource usage. Rates are
+// computed against the previous sample so the browser only renders numbers.
+type Sample struct {
+	Time           time.Time `json:"time"`
+	CPUPercent     float64   `json:"cpuPercent"`
+	MemoryUsage    uint64    `json:"memoryUsage"`
+	MemoryLimit    uint64    `json:"memoryLimit"`
+	MemoryPercent  float64   `json:"memoryPercent"`
+	NetworkRx      uint64    `json:"networkRx"`
+	NetworkTx      uint64    `json:"networkTx"`
+	NetworkRxRate  float64   `json:"networkRxRate"`
+	NetworkTxRate  float64   `json:"networkTxRate"`
+	BlockRead      uint64    `json:"blockRead"`
+	BlockWrite     uint64    `json:"blockWrite"`
+	BlockReadRate  float64   `json:"blockReadRate"`
+	BlockWriteRate float64   `json:"blockWriteRate"`
+	PIDs           uint64    `json:"pids"`
+}
+
+// NewSample converts a raw Docker stats frame into a Sample. previous is the
+// frame received before current and may be nil for the first one.
+func NewSample(current *containertypes.StatsResponse, previous *containertypes.StatsResponse) Sample {
+	sample := Sample{
+		Time:        current.Read,
+		CPUPercent:  cpuPercent(current),
+		MemoryUsage: memoryUsage(current.MemoryStats),
+		MemoryLimit: current.MemoryStats.Limit,
+		PIDs:        current.PidsStats.Current,
+	}
+
+	if sample.MemoryLimit > 0 {
+		sample.MemoryPercent = float64(sample.MemoryUsage) / float64(sample.MemoryLimit) * 100
+	}
+
+	sample.NetworkRx, sample.NetworkTx = networkTotals(current)
+	sample.BlockRead, sample.BlockWrite = blockTotals(current)
+
+	if previous == nil {
+		return sample
+	}
+
+	elapsed := current.Read.Sub(previous.Read).Seconds()
+	if elapsed <= 0 {
+		return sample
+	}
+
+	previousRx, previousTx := networkTotals(previous)
+	previousRead, previousWrite := blockTotals(previous)
+
+	sample.NetworkRxRate = rate(sample.NetworkRx, previousRx, elapsed)
+	sample.NetworkTxRate = rate(sample.NetworkTx, previousTx, elapsed)
+	sample.BlockReadRate = rate(sample.BlockRead, previousRead, elapsed)
+	sample.BlockWriteRate = rate(sample.BlockWrite, previousWrite, elapsed)
+
+	return sample
+}
+
+// cpuPercent follows the same formula as `docker stats`, using the previous
+// CPU reading that the daemon embeds in every frame
+func cpuPercent(stats *containertypes.StatsResponse) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// memoryUsage excludes the page cache the same way `docker stats` does, for
+// both cgroup v1 and v2 hosts
+func memoryUsage(memory containertypes.MemoryStats) uint64 {
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if inactive, ok := memory.Stats[key]; ok && inactive < memory.Usage {
+			return memory.Usage - inactive
+		}
+	}
+	return memory.Usage
+}
+
+func networkTotals(stats *containertypes.StatsResponse) (uint64, uint64) {
+	var rx, tx uint64
+	for _, network := range stats.Networks {
+		rx += network.RxBytes
+		tx += network.TxBytes
+	}
+	return rx, tx
+}
+
+func blockTotals(stats *containertypes.StatsResponse) (uint64, uint64) {
+	var read, write uint64
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += entry.Value
+		case "write":
+			write += entry.Value
+		}
+	}
+	return read, write
+}
+
+// rate returns the per-second change between two counters. Counters reset when
+// a container restarts, in which case the rate is reported as zero.
+func rate(current uint64, previous uint64, elapsed float64) float64 {
+	if current < previous {
+		return 0
+	}
+	return float64(current-previous) / elapsed
+}
@@ -0,0 +1,86 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+
+	"github.com/dwui/cmd/docker"
+)
+
+func Socket(w http.ResponseWriter, r *http.Request) {
+	var containerID = chi.URLParam(r, "containerID")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cli, err := docker.Client()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	var upgrader = websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true // for local dev, allow all origins
+		},
+	}
+	wsConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		http.Error(w, "WebSocket upgrade failed", http.StatusInternalServerError)
+		return
+	}
+	defer wsConn.Close()
+
+	// Stop streaming stats as soon as the browser goes away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := wsConn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	statsResp, err := cli.ContainerStats(ctx, containerID, true)
+	if err != nil {
+		log.Println("Error streaming stats:", err)
+		return
+	}
+	defer statsResp.Body.Close()
+
+	decoder := json.NewDecoder(statsResp.Body)
+	var previous *containertypes.StatsResponse
+	for {
+		var current containertypes.StatsResponse
+		if err := decoder.Decode(&current); err != nil {
+			return
+		}
+
+		err = wsConn.WriteJSON(NewSample(&current, previous))
+		if err != nil {
+			return
+		}
+		previous = &current
+	}
+}
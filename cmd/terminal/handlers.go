@@ -20,13 +20,24 @@ import (
 	"embed"
 	"html/template"
 	"net/http"
+	"net/url"
+	"os"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/dwui/cmd/docker"
 )
 
 type ShowPageData struct {
 	ContainerID   string
 	ContainerName string
+	Options       ExecOptions
+	Shells        []string
+	Query         string
+	Session       string
+	Sessions      []SessionInfo
+	Recording     bool
+	Error         string
 }
 
 func Show(templateFS embed.FS) http.HandlerFunc {
@@ -46,10 +57,113 @@ func Show(templateFS embed.FS) http.HandlerFunc {
 		data := ShowPageData{
 			ContainerID:   containerID,
 			ContainerName: containerName,
+			Shells:        Shells,
+		}
+
+		options, err := ParseExecOptions(req.URL.Query())
+		if err != nil {
+			data.Error = err.Error()
+			options = ExecOptions{}
+		}
+		data.Options = options
+		data.Query = options.Query().Encode()
+		data.Session = req.URL.Query().Get("session")
+		data.Sessions = Sessions(containerID)
+		data.Recording = RecordingEnabled()
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/terminal/show.gohtml"))
+
+		tmpl.Execute(w, data)
+	}
+}
+
+// SessionList renders the running sessions of a container, for the browser
+// to attach to
+func SessionList(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var containerID = chi.URLParam(req, "containerID")
+
+		data := ShowPageData{
+			ContainerID: containerID,
+			Sessions:    Sessions(containerID),
 		}
 
 		tmpl := template.Must(template.ParseFS(templateFS, "cmd/terminal/show.gohtml"))
+		tmpl.ExecuteTemplate(w, "sessions", data)
+	}
+}
+
+type RecordingsPageData struct {
+	Recordings []Recording
+	Filter     RecordingFilter
+	Enabled    bool
+	Error      string
+}
+
+// Recordings lists the recorded terminal sessions, filtered by container
+// and by who ran them
+func Recordings(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		data := RecordingsPageData{
+			Filter: RecordingFilter{
+				Container: query.Get("container"),
+				RanBy:     query.Get("by"),
+			},
+			Enabled: RecordingEnabled(),
+		}
+
+		recordings, err := ListRecordings(data.Filter)
+		if err != nil {
+			data.Error = err.Error()
+		}
+		data.Recordings = recordings
+
+		docker.ReplaceURL(w, "recordings", url.Values{"container": {data.Filter.Container}, "by": {data.Filter.RanBy}})
 
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/terminal/recordings.gohtml"))
 		tmpl.Execute(w, data)
 	}
 }
+
+// Playback renders the player of a recording
+func Playback(templateFS embed.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		recording, found, err := FindRecording(chi.URLParam(req, "recordingID"))
+		if err != nil || !found {
+			http.Error(w, "Recording not found", http.StatusNotFound)
+			return
+		}
+
+		tmpl := template.Must(template.ParseFS(templateFS, "cmd/terminal/recordings.gohtml"))
+		tmpl.ExecuteTemplate(w, "player", recording)
+	}
+}
+
+// Cast serves the asciicast file of a recording
+func Cast(w http.ResponseWriter, req *http.Request) {
+	recording, found, err := FindRecording(chi.URLParam(req, "recordingID"))
+	if err != nil || !found || !RecordingEnabled() {
+		http.Error(w, "Recording not found", http.StatusNotFound)
+		return
+	}
+
+	file, err := os.Open(recording.Path())
+	if err != nil {
+		http.Error(w, "Recording file not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Recording file error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	if req.URL.Query().Get("download") == "1" {
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+recording.ID+".cast\"")
+	}
+	http.ServeContent(w, req, recording.ID+".cast", info.ModTime(), file)
+}
@@ -0,0 +1,326 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// recordingDir is where session recordings are written, recording is
+// disabled while it is empty
+var recordingDir string
+
+// Recording describes the asciicast recording of a terminal session. It
+// shares the ID of the session. User is the user the command ran as, empty
+// for the container default. Everyone signs in with the same password, so
+// the person who ran the session is told by SignIn, the sign-in session of
+// their browser, and RemoteAddr, the address it connected from.
+type Recording struct {
+	ID            string    `json:"id"`
+	ContainerID   string    `json:"containerId"`
+	ContainerName string    `json:"containerName"`
+	Command       string    `json:"command"`
+	User          string    `json:"user"`
+	SignIn        string    `json:"signIn"`
+	RemoteAddr    string    `json:"remoteAddr"`
+	Started       time.Time `json:"started"`
+	Ended         time.Time `json:"ended"`
+}
+
+// Path is the asciicast file of the recording
+func (r Recording) Path() string {
+	return filepath.Join(recordingDir, r.ID+".cast")
+}
+
+// metadataPath is the file describing the recording, kept next to the
+// asciicast file so the recordings directory is self-contained
+func metadataPath(id string) string {
+	return filepath.Join(recordingDir, id+".json")
+}
+
+// Duration is how long the session ran, or has been running
+func (r Recording) Duration() time.Duration {
+	end := r.Ended
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(r.Started).Round(time.Second)
+}
+
+// StartRecording records every terminal session started from now on to an
+// asciicast v2 file in dir, with the output, the input and the resizes of
+// the session
+func StartRecording(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create recordings directory: %v", err)
+	}
+	recordingDir = dir
+
+	// Sessions do not survive a restart, recordings left open ended with
+	// their last write
+	recordings, err := ListRecordings(RecordingFilter{})
+	if err != nil {
+		return err
+	}
+	for _, recording := range recordings {
+		if !recording.Ended.IsZero() {
+			continue
+		}
+		recording.Ended = recording.Started
+		if info, err := os.Stat(recording.Path()); err == nil {
+			recording.Ended = info.ModTime()
+		}
+		if err := storeRecording(recording); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordingEnabled reports whether terminal sessions are recorded
+func RecordingEnabled() bool {
+	return recordingDir != ""
+}
+
+// recorder writes the events of a session to its asciicast file as they
+// happen, so the recording is complete up to a crash
+type recorder struct {
+	mu        sync.Mutex
+	recording Recording
+	file      *os.File
+	encoder   *json.Encoder
+	// pending holds the start of a UTF-8 character split across output
+	// chunks, asciicast events must be valid strings
+	pending []byte
+	closed  bool
+}
+
+// asciicastHeader is the first line of an asciicast v2 file
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint              `json:"width"`
+	Height    uint              `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title"`
+	Env       map[string]string `json:"env"`
+}
+
+func newRecorder(recording Recording, size *[2]uint) (*recorder, error) {
+	width, height := uint(80), uint(24)
+	if size != nil {
+		height, width = size[0], size[1]
+	}
+
+	file, err := os.OpenFile(recording.Path(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &recorder{recording: recording, file: file, encoder: json.NewEncoder(file)}
+	err = r.encoder.Encode(asciicastHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: recording.Started.Unix(),
+		Title:     recording.ContainerName + ": " + recording.Command,
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	})
+	if err == nil {
+		err = storeRecording(recording)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// output records what the process wrote
+func (r *recorder) output(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data = append(r.pending, data...)
+	cut := len(data)
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				cut = len(data) - i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		r.event("o", string(data[:cut]))
+	}
+}
+
+// input records what was typed
+func (r *recorder) input(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("i", string(data))
+}
+
+// resize records a change of the terminal size
+func (r *recorder) resize(cols, rows uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// event appends an [elapsed seconds, type, data] line
+func (r *recorder) event(kind string, data string) {
+	if r.closed {
+		return
+	}
+	elapsed := time.Since(r.recording.Started).Seconds()
+	if err := r.encoder.Encode([]any{elapsed, kind, data}); err != nil {
+		logRecordingError(r.recording, err)
+	}
+}
+
+// close finishes the recording once the session ended
+func (r *recorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) > 0 {
+		r.event("o", string(r.pending))
+		r.pending = nil
+	}
+	r.closed = true
+	if err := r.file.Close(); err != nil {
+		logRecordingError(r.recording, err)
+	}
+	r.recording.Ended = time.Now()
+	if err := storeRecording(r.recording); err != nil {
+		logRecordingError(r.recording, err)
+	}
+}
+
+func logRecordingError(recording Recording, err error) {
+	log.Printf("Terminal recording error for %s: %v", recording.ID, err)
+}
+
+// storeRecording writes the metadata of a recording. It goes through a
+// temporary file so that a crash never leaves it half written.
+func storeRecording(recording Recording) error {
+	data, err := json.Marshal(recording)
+	if err != nil {
+		return err
+	}
+
+	temp := metadataPath(recording.ID) + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, metadataPath(recording.ID))
+}
+
+// FindRecording returns the description of a recording
+func FindRecording(id string) (Recording, bool, error) {
+	if !RecordingEnabled() || !validRecordingID.MatchString(id) {
+		return Recording{}, false, nil
+	}
+
+	recording, err := readRecording(metadataPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Recording{}, false, nil
+	}
+	return recording, err == nil, err
+}
+
+// validRecordingID matches the session IDs recordings are named after, so
+// an ID from a URL cannot point outside the recordings directory
+var validRecordingID = regexp.MustCompile(`^[0-9a-f]+$`)
+
+func readRecording(path string) (Recording, error) {
+	var recording Recording
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return recording, err
+	}
+	err = json.Unmarshal(data, &recording)
+	return recording, err
+}
+
+// RecordingFilter selects recordings by container name or ID and by who
+// ran them, given as a sign-in session or an address. Empty fields match
+// everything.
+type RecordingFilter struct {
+	Container string
+	RanBy     string
+}
+
+// Match reports whether a recording passes the filter
+func (f RecordingFilter) Match(recording Recording) bool {
+	if f.Container != "" &&
+		!strings.Contains(recording.ContainerName, f.Container) &&
+		!strings.HasPrefix(recording.ContainerID, f.Container) {
+		return false
+	}
+	return f.RanBy == "" ||
+		(recording.SignIn != "" && strings.HasPrefix(recording.SignIn, f.RanBy)) ||
+		strings.Contains(recording.RemoteAddr, f.RanBy)
+}
+
+// ListRecordings returns the recordings passing filter, newest first. They
+// are read from the recordings directory, which holds the metadata of every
+// recording next to its asciicast file.
+func ListRecordings(filter RecordingFilter) ([]Recording, error) {
+	list := []Recording{}
+	if !RecordingEnabled() {
+		return list, nil
+	}
+
+	entries, err := os.ReadDir(recordingDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		recording, err := readRecording(filepath.Join(recordingDir, entry.Name()))
+		if err != nil {
+			log.Printf("Terminal recording %s is unreadable: %v", entry.Name(), err)
+			continue
+		}
+		if filter.Match(recording) {
+			list = append(list, recording)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Started.After(list[j].Started)
+	})
+	return list, nil
+}
@@ -0,0 +1,418 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+
+	"github.com/dwui/cmd/docker"
+	"github.com/dwui/cmd/processes"
+)
+
+const (
+	// scrollbackSize is how much recent output a session keeps to replay
+	// when a browser attaches
+	scrollbackSize = 256 * 1024
+
+	// attachmentBuffer is how many output chunks may wait for a slow
+	// browser before it is detached
+	attachmentBuffer = 256
+
+	// hangupGrace is how long a reaped process may take to exit after
+	// SIGHUP before it is killed
+	hangupGrace = 5 * time.Second
+)
+
+// Session is an exec running in a container. It outlives the sockets of
+// the browsers attached to it, which receive its output and may all type
+// into it, until the process exits or it stays detached for too long.
+type Session struct {
+	ID          string
+	ContainerID string
+	Command     string
+	User        string
+	Created     time.Time
+
+	execID   string
+	conn     types.HijackedResponse
+	recorder *recorder
+
+	mu          sync.Mutex
+	scrollback  []byte
+	attachments map[*attachment]bool
+	detached    time.Time
+	reaped      bool
+	ended       bool
+	reason      string
+}
+
+// attachment is a browser socket following the output of a session
+type attachment struct {
+	output chan []byte
+}
+
+// SessionInfo describes a running session for the session list
+type SessionInfo struct {
+	ID       string
+	Command  string
+	User     string
+	Created  time.Time
+	Attached int
+}
+
+var registry = struct {
+	sync.Mutex
+	sessions map[string]*Session
+}{sessions: map[string]*Session{}}
+
+// StartSession starts cmd in a container and registers it as a session.
+// When recording is enabled the session does not start unless its
+// recording does; remoteAddr and the signIn session of the browser are kept
+// with the recording.
+func StartSession(ctx context.Context, containerID string, cmd []string, options ExecOptions, size *[2]uint, remoteAddr string, signIn string) (*Session, error) {
+	cli, err := docker.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	execResp, err := cli.ContainerExecCreate(ctx, containerID, containertypes.ExecOptions{
+		Cmd:          cmd,
+		User:         options.User,
+		WorkingDir:   options.WorkingDir,
+		Env:          options.Env,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          true,
+		ConsoleSize:  size,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := cli.ContainerExecAttach(ctx, execResp.ID, containertypes.ExecStartOptions{
+		Tty:         true,
+		ConsoleSize: size,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Sessions are listed by the full ID whatever the browser asked with
+	if inspect, err := cli.ContainerExecInspect(ctx, execResp.ID); err == nil {
+		containerID = inspect.ContainerID
+	}
+
+	session := &Session{
+		ID:          hex.EncodeToString(id),
+		ContainerID: containerID,
+		Command:     strings.Join(cmd, " "),
+		User:        options.User,
+		Created:     time.Now(),
+		execID:      execResp.ID,
+		conn:        conn,
+		attachments: map[*attachment]bool{},
+		detached:    time.Now(),
+	}
+
+	if RecordingEnabled() {
+		recording := Recording{
+			ID:          session.ID,
+			ContainerID: containerID,
+			Command:     session.Command,
+			User:        session.User,
+			SignIn:      signIn,
+			RemoteAddr:  remoteAddr,
+			Started:     session.Created,
+		}
+		if inspect, err := cli.ContainerInspect(ctx, containerID); err == nil {
+			recording.ContainerName = strings.TrimPrefix(inspect.Name, "/")
+		}
+		if session.recorder, err = newRecorder(recording, size); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("session recording failed: %v", err)
+		}
+	}
+
+	registry.Lock()
+	registry.sessions[session.ID] = session
+	registry.Unlock()
+
+	go session.run()
+	return session, nil
+}
+
+// FindSession returns a running session of a container
+func FindSession(containerID string, sessionID string) (*Session, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	session, ok := registry.sessions[sessionID]
+	if !ok || !sameContainer(session.ContainerID, containerID) {
+		return nil, false
+	}
+	return session, true
+}
+
+// Sessions lists the running sessions of a container, oldest first
+func Sessions(containerID string) []SessionInfo {
+	registry.Lock()
+	list := []*Session{}
+	for _, session := range registry.sessions {
+		if sameContainer(session.ContainerID, containerID) {
+			list = append(list, session)
+		}
+	}
+	registry.Unlock()
+
+	infos := []SessionInfo{}
+	for _, session := range list {
+		session.mu.Lock()
+		infos = append(infos, SessionInfo{
+			ID:       session.ID,
+			Command:  session.Command,
+			User:     session.User,
+			Created:  session.Created,
+			Attached: len(session.attachments),
+		})
+		session.mu.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Created.Before(infos[j].Created)
+	})
+	return infos
+}
+
+// sameContainer reports whether id, as stored in a session, is the
+// container the browser refers to by its full or short ID
+func sameContainer(id string, containerID string) bool {
+	return containerID != "" && strings.HasPrefix(id, containerID)
+}
+
+// StartReaper ends the sessions no browser was attached to for idleTimeout.
+// Their process is hung up and then killed, since closing its input only
+// ends shells, not commands like top or tail -f. A process that cannot be
+// signalled is logged as left running.
+func StartReaper(idleTimeout time.Duration) {
+	go func() {
+		ticker := time.NewTicker(min(idleTimeout, time.Minute))
+		defer ticker.Stop()
+
+		for range ticker.C {
+			registry.Lock()
+			sessions := []*Session{}
+			for _, session := range registry.sessions {
+				sessions = append(sessions, session)
+			}
+			registry.Unlock()
+
+			for _, session := range sessions {
+				session.mu.Lock()
+				idle := !session.reaped && len(session.attachments) == 0 && time.Since(session.detached) > idleTimeout
+				if idle {
+					session.reaped = true
+				}
+				session.mu.Unlock()
+				if idle {
+					log.Printf("Closing terminal session %s, detached for more than %s", session.ID, idleTimeout)
+					go session.kill()
+				}
+			}
+		}
+	}()
+}
+
+// kill ends the process of the session: SIGHUP first, like a closed
+// terminal, then SIGKILL if it is still running after hangupGrace. The PID
+// comes from the exec inspect and is signalled with `kill` inside the
+// container, or from the host for images without it.
+func (s *Session) kill() {
+	defer s.conn.Close()
+
+	cli, err := docker.Client()
+	if err != nil {
+		return
+	}
+
+	ctx := context.Background()
+	for i, signal := range []string{"HUP", "KILL"} {
+		if i > 0 {
+			time.Sleep(hangupGrace)
+		}
+		inspect, err := cli.ContainerExecInspect(ctx, s.execID)
+		if err != nil || !inspect.Running || inspect.Pid == 0 {
+			return
+		}
+		if _, err := processes.Signal(ctx, cli, s.ContainerID, inspect.Pid, signal); err != nil {
+			if hostErr := processes.SignalHost(ctx, cli, s.ContainerID, inspect.Pid, signal); hostErr != nil {
+				log.Printf("Terminal session %s left running, SIG%s failed in the container (%v) and from the host (%v)", s.ID, signal, err, hostErr)
+				return
+			}
+		}
+	}
+}
+
+// run copies the output of the exec to the scrollback and the attached
+// browsers until the process ends
+func (s *Session) run() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.conn.Reader.Read(buf)
+		if n > 0 {
+			if s.recorder != nil {
+				s.recorder.output(buf[:n])
+			}
+			s.broadcast(append([]byte(nil), buf[:n]...))
+		}
+		if err != nil {
+			break
+		}
+	}
+	s.conn.Close()
+	if s.recorder != nil {
+		s.recorder.close()
+	}
+
+	reason := "Session ended"
+	if cli, err := docker.Client(); err == nil {
+		inspect, err := cli.ContainerExecInspect(context.Background(), s.execID)
+		if err == nil && !inspect.Running {
+			reason = fmt.Sprintf("Process exited with code %d", inspect.ExitCode)
+		}
+	}
+
+	registry.Lock()
+	delete(registry.sessions, s.ID)
+	registry.Unlock()
+
+	s.mu.Lock()
+	s.ended = true
+	s.reason = reason
+	for a := range s.attachments {
+		s.detachLocked(a)
+	}
+	s.mu.Unlock()
+}
+
+// broadcast keeps chunk in the scrollback and sends it to every attached
+// browser. Browsers too slow to keep up are detached, they reattach and
+// catch up from the scrollback.
+func (s *Session) broadcast(chunk []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scrollback = append(s.scrollback, chunk...)
+	if excess := len(s.scrollback) - scrollbackSize; excess > 0 {
+		// Cut at a line break when there is one, so the replay starts clean
+		cut := excess
+		if i := bytes.IndexByte(s.scrollback[excess:min(len(s.scrollback), excess+1024)], '\n'); i >= 0 {
+			cut += i + 1
+		}
+		s.scrollback = append([]byte(nil), s.scrollback[cut:]...)
+	}
+
+	for a := range s.attachments {
+		select {
+		case a.output <- chunk:
+		default:
+			s.detachLocked(a)
+		}
+	}
+}
+
+// attach registers a browser and returns the scrollback to replay before
+// the output that follows on the attachment
+func (s *Session) attach() (*attachment, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return nil, nil, errors.New("the session has ended")
+	}
+	a := &attachment{output: make(chan []byte, attachmentBuffer)}
+	s.attachments[a] = true
+	return a, append([]byte(nil), s.scrollback...), nil
+}
+
+// detach stops sending output to a browser, the session keeps running
+func (s *Session) detach(a *attachment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detachLocked(a)
+}
+
+func (s *Session) detachLocked(a *attachment) {
+	if !s.attachments[a] {
+		return
+	}
+	delete(s.attachments, a)
+	close(a.output)
+	if len(s.attachments) == 0 {
+		s.detached = time.Now()
+	}
+}
+
+// Ended reports whether the process exited, and how
+func (s *Session) Ended() (bool, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ended, s.reason
+}
+
+// Write sends input to the process
+func (s *Session) Write(data []byte) error {
+	if s.recorder != nil {
+		s.recorder.input(data)
+	}
+	_, err := s.conn.Conn.Write(data)
+	return err
+}
+
+// Resize changes the terminal size of the process
+func (s *Session) Resize(cols, rows uint) error {
+	if s.recorder != nil {
+		s.recorder.resize(cols, rows)
+	}
+
+	cli, err := docker.Client()
+	if err != nil {
+		return err
+	}
+	return cli.ContainerExecResize(context.Background(), s.execID, containertypes.ResizeOptions{
+		Height: rows,
+		Width:  cols,
+	})
+}
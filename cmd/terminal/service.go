@@ -0,0 +1,218 @@
+// DWUI (Docker Web UI)
+// Copyright (C) 2025 Romer Ramos
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// Shells are tried in order when no command is given
+var Shells = []string{"/bin/bash", "/bin/sh", "/bin/ash"}
+
+// ExecOptions selects the process a terminal runs. Command is split into
+// arguments like a shell would, honoring quotes and backslashes; when it is
+// empty the first of Shells found in the container is run. Env holds
+// "KEY=VALUE" variables added to the environment, values may hold spaces.
+type ExecOptions struct {
+	Command    string
+	User       string
+	WorkingDir string
+	Env        []string
+}
+
+// ParseExecOptions reads the exec options from the terminal query
+func ParseExecOptions(query url.Values) (ExecOptions, error) {
+	options := ExecOptions{
+		Command:    strings.TrimSpace(query.Get("cmd")),
+		User:       strings.TrimSpace(query.Get("user")),
+		WorkingDir: strings.TrimSpace(query.Get("workdir")),
+	}
+
+	for _, variable := range query["env"] {
+		if strings.TrimSpace(variable) == "" {
+			continue
+		}
+		if key, _, ok := strings.Cut(variable, "="); !ok || key == "" {
+			return options, errors.New("invalid environment variable: must be KEY=VALUE")
+		}
+		options.Env = append(options.Env, variable)
+	}
+
+	if _, err := splitWords(options.Command); err != nil {
+		return options, errors.New("invalid command: " + err.Error())
+	}
+	if options.WorkingDir != "" && !strings.HasPrefix(options.WorkingDir, "/") {
+		return options, errors.New("invalid working directory: must be an absolute path")
+	}
+	return options, nil
+}
+
+// Query encodes the options back into URL query values
+func (o ExecOptions) Query() url.Values {
+	query := url.Values{}
+	if o.Command != "" {
+		query.Set("cmd", o.Command)
+	}
+	if o.User != "" {
+		query.Set("user", o.User)
+	}
+	if o.WorkingDir != "" {
+		query.Set("workdir", o.WorkingDir)
+	}
+	for _, variable := range o.Env {
+		query.Add("env", variable)
+	}
+	return query
+}
+
+// Cmd returns the command to exec, looking for a shell when none was given
+func (o ExecOptions) Cmd(ctx context.Context, cli *client.Client, containerID string) ([]string, error) {
+	if o.Command != "" {
+		return splitWords(o.Command)
+	}
+
+	// Stat works on images without any tools, unlike running test or which
+	for _, shell := range Shells {
+		if _, err := cli.ContainerStatPath(ctx, containerID, shell); err == nil {
+			return []string{shell}, nil
+		}
+	}
+	return nil, errors.New("no shell found in the container (tried " + strings.Join(Shells, ", ") + "), enter a command to run instead")
+}
+
+// splitWords splits a command line into arguments the way a POSIX shell
+// does, without any expansion. Single quotes keep everything literally,
+// double quotes keep spaces and let a backslash escape ", \, $ and `, and a
+// backslash outside quotes escapes the next character.
+func splitWords(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"$`\\", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	switch {
+	case escaped:
+		return nil, errors.New("trailing backslash")
+	case quote != 0:
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Message is a control frame on the terminal socket. The browser sends data
+// messages with keyboard input and resize messages with the new size of the
+// terminal in character cells:
+//
+//	{"type":"data","data":"ls\r"}
+//	{"type":"resize","cols":120,"rows":40}
+//
+// The server sends the output of the process as binary frames, and a
+// session message naming the session the socket is attached to:
+//
+//	{"type":"session","session":"3f2a9c0d1e4b5a67"}
+type Message struct {
+	Type    string `json:"type"`
+	Data    string `json:"data,omitempty"`
+	Cols    uint   `json:"cols,omitempty"`
+	Rows    uint   `json:"rows,omitempty"`
+	Session string `json:"session,omitempty"`
+}
+
+// Message types
+const (
+	MessageData    = "data"
+	MessageResize  = "resize"
+	MessageSession = "session"
+)
+
+// maxSize bounds the terminal size accepted from the browser
+const maxSize = 1000
+
+// ParseMessage reads a frame sent by the browser
+func ParseMessage(data []byte) (Message, error) {
+	var message Message
+	if err := json.Unmarshal(data, &message); err != nil {
+		return message, err
+	}
+
+	switch message.Type {
+	case MessageData:
+	case MessageResize:
+		if !validSize(message.Cols, message.Rows) {
+			return message, errors.New("invalid terminal size")
+		}
+	default:
+		return message, errors.New("unknown terminal message type " + strconv.Quote(message.Type))
+	}
+	return message, nil
+}
+
+// ParseSize reads the initial terminal size from the socket query, as the
+// [height, width] pair Docker expects. It is nil when no valid size is given.
+func ParseSize(query url.Values) *[2]uint {
+	cols, _ := strconv.ParseUint(query.Get("cols"), 10, 0)
+	rows, _ := strconv.ParseUint(query.Get("rows"), 10, 0)
+	if !validSize(uint(cols), uint(rows)) {
+		return nil
+	}
+	return &[2]uint{uint(rows), uint(cols)}
+}
+
+func validSize(cols, rows uint) bool {
+	return cols > 0 && rows > 0 && cols <= maxSize && rows <= maxSize
+}
@@ -18,26 +18,36 @@ package terminal
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 
-	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/go-chi/chi/v5"
-
-	"github.com/docker/docker/client"
 	"github.com/gorilla/websocket"
+
+	"github.com/dwui/cmd/docker"
+	authsession "github.com/dwui/cmd/session"
 )
 
+// Socket attaches the browser to a terminal session of a container. Without
+// a session in the query it starts a new one running a shell, or the command
+// given in the query. Closing the socket only detaches, the session keeps
+// running until its process exits or it is reaped.
+//
+// The socket first sends the session message, then replays the scrollback
+// and follows the output; the browser sends Message frames for input and
+// size changes. Sessions that cannot start or be found are closed with a
+// policy violation and the reason, so the browser does not retry them.
 func Socket(w http.ResponseWriter, r *http.Request) {
 	var containerID = chi.URLParam(r, "containerID")
 	ctx := context.Background()
+	query := r.URL.Query()
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := docker.Client()
 	if err != nil {
-		http.Error(w, "Docker client error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		return
 	}
-	defer cli.Close()
 
 	var upgrader = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -51,51 +61,94 @@ func Socket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer wsConn.Close()
 
-	execResp, err := cli.ContainerExecCreate(ctx, containerID, containertypes.ExecOptions{
-		Cmd:          []string{"/bin/bash"},
-		AttachStdin:  true,
-		AttachStdout: true,
-		AttachStderr: true,
-		Tty:          true,
-	})
+	fail := func(err error) {
+		wsConn.WriteMessage(websocket.BinaryMessage, []byte("\x1b[31m"+err.Error()+"\x1b[0m\r\n"))
+		wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "Terminal session failed"))
+	}
+
+	var session *Session
+	if sessionID := query.Get("session"); sessionID != "" {
+		var found bool
+		if session, found = FindSession(containerID, sessionID); !found {
+			fail(errors.New("the terminal session has ended"))
+			return
+		}
+		if size := ParseSize(query); size != nil {
+			session.Resize(size[1], size[0])
+		}
+	} else {
+		options, err := ParseExecOptions(query)
+		if err != nil {
+			fail(err)
+			return
+		}
+		cmd, err := options.Cmd(ctx, cli, containerID)
+		if err != nil {
+			fail(err)
+			return
+		}
+		signIn := ""
+		if cookie, err := r.Cookie(authsession.SessionCookieName); err == nil {
+			signIn = authsession.ID(cookie.Value)
+		}
+		if session, err = StartSession(ctx, containerID, cmd, options, ParseSize(query), r.RemoteAddr, signIn); err != nil {
+			log.Println("Exec start error:", err)
+			fail(err)
+			return
+		}
+	}
+
+	attached, replay, err := session.attach()
 	if err != nil {
-		log.Println("Exec create error:", err)
+		fail(err)
 		return
 	}
+	defer session.detach(attached)
 
-	hijackResp, err := cli.ContainerExecAttach(ctx, execResp.ID, containertypes.ExecStartOptions{Tty: true})
-	if err != nil {
-		log.Println("Exec attach error:", err)
+	if err := wsConn.WriteJSON(Message{Type: MessageSession, Session: session.ID}); err != nil {
+		return
+	}
+	if err := wsConn.WriteMessage(websocket.BinaryMessage, replay); err != nil {
 		return
 	}
-	defer hijackResp.Close()
 
-	// Stream container output to browser
+	// Forward browser input and size changes to the session, the browser
+	// leaving only detaches it
 	go func() {
+		defer session.detach(attached)
 		for {
-			msgType, msg, err := wsConn.ReadMessage()
+			_, msg, err := wsConn.ReadMessage()
 			if err != nil {
 				return
 			}
-			if msgType == websocket.TextMessage || msgType == websocket.BinaryMessage {
-				_, err := hijackResp.Conn.Write(msg)
-				if err != nil {
+			message, err := ParseMessage(msg)
+			if err != nil {
+				log.Println("Terminal message error:", err)
+				continue
+			}
+
+			switch message.Type {
+			case MessageData:
+				if err := session.Write([]byte(message.Data)); err != nil {
 					return
 				}
+			case MessageResize:
+				if err := session.Resize(message.Cols, message.Rows); err != nil {
+					log.Println("Exec resize error:", err)
+				}
 			}
 		}
 	}()
 
-	// Read from container and write to websocket
-	buf := make([]byte, 1024)
-	for {
-		n, err := hijackResp.Reader.Read(buf)
-		if err != nil {
-			return
-		}
-		err = wsConn.WriteMessage(websocket.TextMessage, buf[:n])
-		if err != nil {
+	// Write the session output to websocket until either side goes away
+	for chunk := range attached.output {
+		if err := wsConn.WriteMessage(websocket.BinaryMessage, chunk); err != nil {
 			return
 		}
 	}
+
+	// The process ended, tell the browser how so it does not start another
+	if ended, reason := session.Ended(); ended {
+		wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason))
+	}
 }
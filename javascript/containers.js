@@ -0,0 +1,136 @@
+/*
+  DWUI (Docker Web UI)
+  Copyright (C) 2025 Romer Ramos
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the GNU Affero General Public License as published by
+  the Free Software Foundation, either version 3 of the License, or
+  (at your option) any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+  GNU Affero General Public License for more details.
+
+  You should have received a copy of the GNU Affero General Public License
+  along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+export default (filterQuery) => {
+  return {
+    socket: null,
+    isConnected: false,
+    destroyed: false,
+    filterQuery: filterQuery,
+    activeContainer: "", // Container whose details are shown
+    activeAction: "", // Which details of it: logs, terminal, inspect...
+
+    // Container events that change how a row looks, the same the server
+    // refreshes its container cache on
+    refreshActions: [],
+
+    handleVisibilityChange() {
+      if (!document.hidden && !this.isConnected) {
+        this.connectWebSocket()
+      }
+    },
+
+    init() {
+      this.refreshActions = this.$el.dataset.refreshActions.split(" ")
+      this.connectWebSocket()
+    },
+
+    connectWebSocket() {
+      const protocol = window.location.protocol === "https:" ? "wss:" : "ws:"
+
+      const locationHost = window.location.host.includes("8082")
+        ? window.location.host.replace("8082", "8300")
+        : window.location.host
+
+      this.socket = new WebSocket(
+        `${protocol}//${locationHost}/events/stream?type=container`,
+      )
+
+      this.socket.onopen = (event) => {
+        console.log("WebSocket connected for container events")
+        this.isConnected = true
+      }
+
+      this.socket.onmessage = (event) => {
+        const dockerEvent = JSON.parse(event.data)
+        if (
+          this.refreshActions.some((action) =>
+            dockerEvent.action.startsWith(action),
+          )
+        ) {
+          this.refreshRow(dockerEvent.id)
+        }
+      }
+
+      this.socket.onclose = (event) => {
+        console.log("WebSocket disconnected for container events")
+        this.isConnected = false
+        if (!this.destroyed) {
+          setTimeout(() => this.connectWebSocket(), 3000)
+        }
+      }
+
+      this.socket.onerror = (error) => {
+        console.error("WebSocket error:", error)
+      }
+    },
+
+    // Re-render a single row. The server answers with nothing when the
+    // container is gone or no longer matches the filters, which drops the row.
+    refreshRow(containerId) {
+      const url = `/containers/row/${containerId}?${this.filterQuery}`
+
+      if (document.getElementById(`row-${containerId}`)) {
+        htmx.ajax("GET", url, {
+          target: `#row-${containerId}`,
+          swap: "outerHTML",
+        })
+      } else if (this.$refs.rows) {
+        this.insertRow(url)
+      } else {
+        // The list was empty, render it again from scratch
+        htmx.ajax("GET", `/containers?${this.filterQuery}`, {
+          target: "#containers",
+          swap: "innerHTML",
+        })
+      }
+    },
+
+    // insertRow adds the row of a container new to the list where it belongs,
+    // the list is ordered newest first like `docker ps`
+    async insertRow(url) {
+      const response = await fetch(url)
+      if (!response.ok) {
+        return
+      }
+      const template = document.createElement("template")
+      template.innerHTML = (await response.text()).trim()
+      const row = template.content.firstElementChild
+      if (!row || document.getElementById(row.id)) {
+        return
+      }
+
+      const created = Number(row.dataset.created)
+      const rows = this.$refs.rows.querySelectorAll("[data-created]")
+      const next = [...rows].find(
+        (other) =>
+          Number(other.dataset.created) < created ||
+          (Number(other.dataset.created) === created && other.id > row.id),
+      )
+      this.$refs.rows.insertBefore(row, next || null)
+      htmx.process(row)
+    },
+
+    // Cleanup when component is destroyed
+    destroy() {
+      this.destroyed = true
+      if (this.socket) {
+        this.socket.close()
+      }
+    },
+  }
+}
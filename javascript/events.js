@@ -0,0 +1,98 @@
+/*
+  DWUI (Docker Web UI)
+  Copyright (C) 2025 Romer Ramos
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the GNU Affero General Public License as published by
+  the Free Software Foundation, either version 3 of the License, or
+  (at your option) any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+  GNU Affero General Public License for more details.
+
+  You should have received a copy of the GNU Affero General Public License
+  along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+export default (streamUrl) => {
+  return {
+    socket: null,
+    isConnected: false,
+    destroyed: false,
+    streamUrl: streamUrl,
+    events: [], // Newest first
+    maxEvents: 500,
+
+    handleVisibilityChange() {
+      if (!document.hidden && !this.isConnected) {
+        this.connectWebSocket()
+      }
+    },
+
+    init() {
+      this.connectWebSocket()
+    },
+
+    connectWebSocket() {
+      const protocol = window.location.protocol === "https:" ? "wss:" : "ws:"
+
+      const locationHost = window.location.host.includes("8082")
+        ? window.location.host.replace("8082", "8300")
+        : window.location.host
+
+      this.socket = new WebSocket(`${protocol}//${locationHost}${this.streamUrl}`)
+
+      this.socket.onopen = (event) => {
+        console.log("WebSocket connected for events")
+        // The backlog is sent again on every connection
+        this.events = []
+        this.isConnected = true
+      }
+
+      this.socket.onmessage = (event) => {
+        this.events.unshift(JSON.parse(event.data))
+        if (this.events.length > this.maxEvents) {
+          this.events.pop()
+        }
+      }
+
+      this.socket.onclose = (event) => {
+        console.log("WebSocket disconnected for events")
+        this.isConnected = false
+        if (!this.destroyed) {
+          setTimeout(() => this.connectWebSocket(), 3000)
+        }
+      }
+
+      this.socket.onerror = (error) => {
+        console.error("WebSocket error:", error)
+      }
+    },
+
+    formatTime(time) {
+      return new Date(time).toLocaleString()
+    },
+
+    actionClass(action) {
+      if (["die", "kill", "oom", "destroy"].includes(action)) {
+        return "text-red-400"
+      }
+      if (action.includes("unhealthy")) {
+        return "text-red-400"
+      }
+      if (["create", "start"].includes(action) || action.includes("healthy")) {
+        return "text-green-400"
+      }
+      return "text-gray-300"
+    },
+
+    // Cleanup when component is destroyed
+    destroy() {
+      this.destroyed = true
+      if (this.socket) {
+        this.socket.close()
+      }
+    },
+  }
+}
@@ -15,13 +15,33 @@
   You should have received a copy of the GNU Affero General Public License
   along with this program. If not, see <https://www.gnu.org/licenses/>.
 */
-export default (containerId) => {
+export default (streamPath, downloadPath, query) => {
+  const options = new URLSearchParams(query)
+
   return {
     socket: null,
     isConnected: false,
-    containerId: containerId,
+    destroyed: false,
+    finished: false, // A bounded range was fully received
+    cursors: {}, // Timestamp of the newest line received per container, to resume after
+    streamPath: streamPath,
+    downloadPath: downloadPath,
+    containerColors: {}, // Hue per container name in merged views
+
+    // Stream options, see logs.StreamOptions
+    since: options.get("since") || "",
+    until: options.get("until") || "",
+    tail: options.get("tail") || "",
+    timestamps: options.get("timestamps") === "1",
+    stream: options.get("stream") || "", // Empty for both stdout and stderr
+    level: options.get("level") || "", // Minimum detected level
+    include: options.get("include") || "", // Regular expressions, see Go's regexp
+    exclude: options.get("exclude") || "",
+    fields: options.getAll("field").join(" "), // Space separated key=value filters
+    source: options.get("source") || "", // "archive" to read the log archive
+    downloadFormat: "text", // Format name, with a ".gz" suffix for gzip
     logs: "",
-    logLines: [], // Array to store individual log lines
+    logLines: [], // Array of { stream, text } log lines
     autoScroll: true, // Auto-scroll toggle state
     userScrolledUp: false, // Track if user manually scrolled up
     fontSize: 12, // Font size in pixels
@@ -37,7 +57,7 @@ export default (containerId) => {
     shiftKeyPressed: false,
 
     handleVisibilityChange() {
-      if (!document.hidden && !this.isConnected) {
+      if (!document.hidden && !this.isConnected && !this.finished) {
         this.connectWebSocket()
       }
     },
@@ -50,12 +70,10 @@ export default (containerId) => {
     },
 
     updateDisplay() {
-      const content = this.logLines.join("\n")
-
       if (this.searchQuery && this.searchMatches.length > 0) {
         this.$refs.logsElement.innerHTML = this.highlightedContent
       } else {
-        this.$refs.logsElement.textContent = content
+        this.$refs.logsElement.innerHTML = this.renderLines(null)
       }
 
       this.updateFontSize()
@@ -84,7 +102,16 @@ export default (containerId) => {
         ? window.location.host.replace("8082", "8300")
         : window.location.host
 
-      const wsUrl = `${protocol}//${locationHost}/logs/stream/${this.containerId}`
+      // After a lost connection the stream resumes after the last line
+      // received instead of starting over. Merged views keep one cursor per
+      // container, since their lines are only ordered within a container.
+      const params = new URLSearchParams(this.streamQuery())
+      for (const [container, cursor] of Object.entries(this.cursors)) {
+        params.append("after", container ? `${container}=${cursor}` : cursor)
+      }
+
+      const separator = this.streamPath.includes("?") ? "&" : "?"
+      const wsUrl = `${protocol}//${locationHost}${this.streamPath}${separator}${params.toString()}`
 
       this.socket = new WebSocket(wsUrl)
 
@@ -94,32 +121,112 @@ export default (containerId) => {
       }
 
       this.socket.onmessage = (event) => {
-        const logData = event.data
-        if (logData.trim()) {
-          this.addLogLines(logData)
+        const line = JSON.parse(event.data)
+        if (line.cursor) {
+          this.cursors[line.container || ""] = line.cursor
         }
+        this.addLogLines([line])
       }
 
       this.socket.onclose = (event) => {
         console.log("WebSocket disconnected for logs")
         this.isConnected = false
-        this.addLogLines(
-          "\n--- Connection lost. Attempting to reconnect... ---",
-        )
+        if (this.destroyed) {
+          return
+        }
+
+        // The server closes normally once a bounded range has been sent, and
+        // with a policy violation when the containers cannot be found. A
+        // followed stream that ended, as when its container restarted, is
+        // resumed like a lost connection.
+        if (event.code === 1000 || event.code === 1008) {
+          this.finished = true
+          this.addStatusLine(`--- ${event.reason || "End of logs"} ---`)
+          return
+        }
+
+        const reason =
+          event.code === 1001 && event.reason ? event.reason : "Connection lost"
+        this.addStatusLine(`--- ${reason}. Attempting to reconnect... ---`)
         setTimeout(() => this.connectWebSocket(), 3000)
       }
 
       this.socket.onerror = (error) => {
         console.error("WebSocket error:", error)
-        this.addLogLines("\n--- Connection error occurred ---")
+        this.addStatusLine("--- Connection error occurred ---")
+      }
+    },
+
+    // streamQuery encodes the stream options for the socket. Dates typed
+    // without a zone are taken as local time and sent in UTC.
+    streamQuery() {
+      const params = new URLSearchParams()
+      const toServerTime = (value) => {
+        value = value.trim()
+        if (/^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}(:\d{2}(\.\d+)?)?$/.test(value)) {
+          return new Date(value).toISOString()
+        }
+        return value
+      }
+
+      if (this.since.trim()) {
+        params.set("since", toServerTime(this.since))
+      }
+      if (this.until.trim()) {
+        params.set("until", toServerTime(this.until))
+      }
+      if (this.tail.trim()) {
+        params.set("tail", this.tail.trim())
       }
+      if (this.timestamps) {
+        params.set("timestamps", "1")
+      }
+      for (const name of ["stream", "level", "include", "exclude", "source"]) {
+        if (this[name]) {
+          params.set(name, this[name])
+        }
+      }
+      for (const field of this.fields.split(/\s+/)) {
+        if (field) {
+          params.append("field", field)
+        }
+      }
+      return params.toString()
+    },
+
+    // downloadURL points to the whole selected range in the chosen format,
+    // the tail only applies to the live view
+    downloadURL() {
+      const params = new URLSearchParams(this.streamQuery())
+      params.delete("tail")
+
+      const [format, compression] = this.downloadFormat.split(".")
+      params.set("format", format)
+      if (compression === "gz") {
+        params.set("gzip", "1")
+      }
+      return `${this.downloadPath}?${params.toString()}`
     },
 
-    addLogLines(newContent) {
-      const newLines = newContent
-        .split("\n")
-        .filter((line) => line.trim() !== "")
+    // applyOptions restarts the stream with the options from the form
+    applyOptions() {
+      if (this.socket) {
+        this.socket.onclose = null
+        this.socket.close()
+      }
+      this.isConnected = false
+      this.finished = false
+      this.cursors = {}
+      this.logLines = []
+      this.hideSearch()
+      this.connectWebSocket()
+    },
 
+    addStatusLine(text) {
+      this.addLogLines([{ stream: "system", text: text }])
+    },
+
+    addLogLines(newLines) {
       // Add new lines to the array (no limit)
       this.logLines.push(...newLines)
 
@@ -131,6 +238,107 @@ export default (containerId) => {
       }
     },
 
+    // renderLines returns the lines as HTML, coloring stderr and wrapping
+    // matches of the search pattern when one is given. JSON lines expand to
+    // their fields.
+    renderLines(pattern) {
+      let matchCount = 0
+
+      return this.logLines
+        .map((line, index) => {
+          let html = this.escape(line.text)
+
+          if (pattern) {
+            html = html.replace(pattern, (match) => {
+              const isCurrentMatch = matchCount === this.currentMatchIndex
+              const className = isCurrentMatch
+                ? "bg-yellow-400 text-black"
+                : "bg-yellow-200 text-black"
+              matchCount++
+              return `<span class="${className}">${match}</span>`
+            })
+          }
+
+          if (line.stream === "stderr") {
+            html = `<span class="text-red-400">${html}</span>`
+          }
+          if (line.stream === "system") {
+            html = `<span class="text-gray-400">${html}</span>`
+          }
+          if (line.container) {
+            const name = this.escape(line.container)
+            html = `<span style="color: hsl(${this.containerHue(line.container)}, 70%, 65%)">${name}</span> ${html}`
+          }
+          if (line.time) {
+            html = `<span class="text-gray-400">${line.time}</span> ${html}`
+          }
+          if (line.fields) {
+            const open = line.expanded ? " open" : ""
+            return `<details data-index="${index}"${open}><summary class="cursor-pointer">${html}</summary>${this.renderFields(line.fields)}</details>`
+          }
+          return html + "\n"
+        })
+        .join("")
+    },
+
+    // renderFields lists the fields of a JSON line, each with a button to
+    // filter the stream on its value
+    renderFields(fields) {
+      return Object.keys(fields)
+        .sort()
+        .map((key) => {
+          const value = fields[key]
+          const filter = /\s/.test(key + value)
+            ? ""
+            : ` <button type="button" class="underline cursor-pointer" data-field-key="${this.escape(key)}" data-field-value="${this.escape(value)}">filter</button>`
+          return `<div class="px-4 text-gray-400">${this.escape(key)}: <span class="text-gray-300">${this.escape(value)}</span>${filter}</div>`
+        })
+        .join("")
+    },
+
+    // Remember which JSON lines are expanded, the display is rebuilt on
+    // every new line
+    handleToggle(event) {
+      const index = event.target.dataset?.index
+      if (index !== undefined && this.logLines[index]) {
+        this.logLines[index].expanded = event.target.open
+      }
+    },
+
+    // Clicking the filter button of a field restarts the stream filtered on it
+    handleFieldClick(event) {
+      const button = event.target.closest("[data-field-key]")
+      if (!button) {
+        return
+      }
+
+      const filter = `${button.dataset.fieldKey}=${button.dataset.fieldValue}`
+      const fields = this.fields.split(/\s+/).filter((field) => field)
+      if (!fields.includes(filter)) {
+        fields.push(filter)
+      }
+      this.fields = fields.join(" ")
+      this.applyOptions()
+    },
+
+    escape(text) {
+      return text
+        .replace(/&/g, "&amp;")
+        .replace(/</g, "&lt;")
+        .replace(/>/g, "&gt;")
+        .replace(/"/g, "&quot;")
+    },
+
+    // containerHue spreads the containers of a merged view around the color
+    // wheel in the order they first show up
+    containerHue(name) {
+      if (!(name in this.containerColors)) {
+        const index = Object.keys(this.containerColors).length
+        this.containerColors[name] = (index * 137) % 360
+      }
+      return this.containerColors[name]
+    },
+
     performSearch() {
       if (!this.searchQuery) {
         this.clearSearch()
@@ -141,13 +349,13 @@ export default (containerId) => {
       const matches = []
 
       this.logLines.forEach((line, lineIndex) => {
-        const lowerLine = line.toLowerCase()
+        const lowerLine = line.text.toLowerCase()
         let index = 0
         while ((index = lowerLine.indexOf(query, index)) !== -1) {
           matches.push({
             lineIndex,
             charIndex: index,
-            line: line,
+            line: line.text,
           })
           index += query.length
         }
@@ -176,29 +384,10 @@ export default (containerId) => {
         return
       }
 
-      const content = this.logLines.join("\n")
       const query = this.searchQuery
       const escapedQuery = query.replace(/[.*+?^${}()|[\]\\]/g, "\\$&")
 
-      let safeContent = content
-        .replace(/&/g, "&amp;")
-        .replace(/</g, "&lt;")
-        .replace(/>/g, "&gt;")
-
-      let matchCount = 0
-      const highlightedContent = safeContent.replace(
-        new RegExp(escapedQuery, "gi"),
-        (match) => {
-          const isCurrentMatch = matchCount === this.currentMatchIndex
-          const className = isCurrentMatch
-            ? "bg-yellow-400 text-black"
-            : "bg-yellow-200 text-black"
-          matchCount++
-          return `<span class="${className}">${match}</span>`
-        },
-      )
-
-      this.highlightedContent = highlightedContent
+      this.highlightedContent = this.renderLines(new RegExp(escapedQuery, "gi"))
     },
 
     handleScroll() {
@@ -292,6 +481,7 @@ export default (containerId) => {
 
     // Cleanup when component is destroyed
     destroy() {
+      this.destroyed = true
       if (this.socket) {
         this.socket.close()
       }
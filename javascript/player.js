@@ -0,0 +1,170 @@
+/*
+  DWUI (Docker Web UI)
+  Copyright (C) 2025 Romer Ramos
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the GNU Affero General Public License as published by
+  the Free Software Foundation, either version 3 of the License, or
+  (at your option) any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+  GNU Affero General Public License for more details.
+
+  You should have received a copy of the GNU Affero General Public License
+  along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+export default (castURL) => {
+  return {
+    terminal: null,
+    header: null, // asciicast v2 header
+    events: [], // [seconds, type, data] events
+    inputs: [], // Input events, listed below the player
+    duration: 0,
+    position: 0, // Seconds into the recording
+    index: 0, // Next event to apply
+    speed: 1,
+    playing: false,
+    timer: null,
+    anchorTime: 0, // performance.now() when position was anchorPosition
+    anchorPosition: 0,
+    error: "",
+
+    async init() {
+      this.terminal = new Terminal({
+        fontFamily: "JetBrains Mono, Fira Code, Courier New, monospace",
+        fontSize: 12,
+        lineHeight: 1.2,
+        disableStdin: true,
+        theme: {
+          background: "#1e2939",
+          foreground: "#ffffff",
+        },
+      })
+      this.terminal.open(this.$refs.playerElement)
+
+      try {
+        const response = await fetch(castURL)
+        if (!response.ok) {
+          throw new Error(await response.text())
+        }
+        const lines = (await response.text()).split("\n").filter(Boolean)
+        this.header = JSON.parse(lines[0])
+
+        // A recording cut short by a crash may end with a partial line
+        for (const line of lines.slice(1)) {
+          try {
+            this.events.push(JSON.parse(line))
+          } catch {
+            break
+          }
+        }
+      } catch (error) {
+        this.error = `Could not load the recording: ${error.message}`
+        return
+      }
+
+      this.inputs = this.events.filter((event) => event[1] === "i")
+      this.duration = this.events.length
+        ? this.events[this.events.length - 1][0]
+        : 0
+      this.seek(0)
+    },
+
+    play() {
+      if (this.position >= this.duration) {
+        this.seek(0)
+      }
+      this.playing = true
+      this.anchor()
+      this.step()
+    },
+
+    pause() {
+      this.playing = false
+      clearTimeout(this.timer)
+    },
+
+    // seek redraws the screen as it was at seconds into the recording
+    seek(seconds) {
+      clearTimeout(this.timer)
+      this.terminal.reset()
+      this.terminal.resize(this.header.width, this.header.height)
+      this.index = 0
+      this.apply(seconds)
+      this.position = seconds
+
+      if (this.playing) {
+        this.anchor()
+        this.step()
+      }
+    },
+
+    setSpeed(speed) {
+      this.speed = speed
+      this.anchor()
+    },
+
+    // anchor restarts the clock at the current position, after a seek or a
+    // change of speed
+    anchor() {
+      this.anchorTime = performance.now()
+      this.anchorPosition = this.position
+    },
+
+    // step applies the events that are due and waits for the next one,
+    // waking up at least every 100ms to move the seek bar
+    step() {
+      if (!this.playing) {
+        return
+      }
+
+      const elapsed = ((performance.now() - this.anchorTime) / 1000) * this.speed
+      this.position = Math.min(this.duration, this.anchorPosition + elapsed)
+      this.apply(this.position)
+
+      if (this.index >= this.events.length) {
+        this.playing = false
+        return
+      }
+      const wait = ((this.events[this.index][0] - this.position) * 1000) / this.speed
+      this.timer = setTimeout(() => this.step(), Math.max(0, Math.min(wait, 100)))
+    },
+
+    // apply writes the events up to seconds that were not written yet
+    apply(seconds) {
+      while (
+        this.index < this.events.length &&
+        this.events[this.index][0] <= seconds
+      ) {
+        const [, type, data] = this.events[this.index]
+        if (type === "o") {
+          this.terminal.write(data)
+        } else if (type === "r") {
+          const [cols, rows] = data.split("x").map(Number)
+          this.terminal.resize(cols, rows)
+        }
+        this.index++
+      }
+    },
+
+    formatTime(seconds) {
+      const minutes = Math.floor(seconds / 60)
+      const rest = Math.floor(seconds % 60)
+      return `${minutes}:${String(rest).padStart(2, "0")}`
+    },
+
+    // describeInput shows control characters of typed input
+    describeInput(data) {
+      return JSON.stringify(data).slice(1, -1)
+    },
+
+    destroy() {
+      clearTimeout(this.timer)
+      if (this.terminal) {
+        this.terminal.dispose()
+      }
+    },
+  }
+}
@@ -0,0 +1,146 @@
+/*
+  DWUI (Docker Web UI)
+  Copyright (C) 2025 Romer Ramos
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the GNU Affero General Public License as published by
+  the Free Software Foundation, either version 3 of the License, or
+  (at your option) any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+  GNU Affero General Public License for more details.
+
+  You should have received a copy of the GNU Affero General Public License
+  along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+export default (containerId) => {
+  return {
+    socket: null,
+    isConnected: false,
+    containerId: containerId,
+    sample: null,
+    destroyed: false,
+    history: [],
+    historyRange: "1h",
+    historyTimer: null,
+
+    handleVisibilityChange() {
+      if (!document.hidden && !this.isConnected) {
+        this.connectWebSocket()
+      }
+    },
+
+    init() {
+      this.connectWebSocket()
+      this.loadHistory()
+
+      // Stored rollups only change once a minute
+      this.historyTimer = setInterval(() => this.loadHistory(), 60000)
+    },
+
+    async loadHistory() {
+      try {
+        const response = await fetch(
+          `/stats/history/${this.containerId}?range=${this.historyRange}`,
+        )
+        if (response.ok) {
+          this.history = await response.json()
+        }
+      } catch (error) {
+        console.error("Error loading stats history:", error)
+      }
+    },
+
+    setHistoryRange(range) {
+      this.historyRange = range
+      this.loadHistory()
+    },
+
+    peak(key) {
+      return this.history.reduce((max, point) => Math.max(max, point[key]), 0)
+    },
+
+    // Scale the history of a metric into the 100x30 viewBox of the charts
+    chartPoints(key) {
+      if (this.history.length === 0) {
+        return ""
+      }
+
+      const max = this.peak(key) || 1
+      const first = new Date(this.history[0].time).getTime()
+      const last = new Date(this.history[this.history.length - 1].time).getTime()
+      const span = last - first || 1
+
+      return this.history
+        .map((point) => {
+          const x = ((new Date(point.time).getTime() - first) / span) * 100
+          const y = 30 - (point[key] / max) * 30
+          return `${x.toFixed(2)},${y.toFixed(2)}`
+        })
+        .join(" ")
+    },
+
+    connectWebSocket() {
+      const protocol = window.location.protocol === "https:" ? "wss:" : "ws:"
+
+      const locationHost = window.location.host.includes("8082")
+        ? window.location.host.replace("8082", "8300")
+        : window.location.host
+
+      const wsUrl = `${protocol}//${locationHost}/stats/stream/${this.containerId}`
+
+      this.socket = new WebSocket(wsUrl)
+
+      this.socket.onopen = (event) => {
+        console.log("WebSocket connected for stats")
+        this.isConnected = true
+      }
+
+      this.socket.onmessage = (event) => {
+        this.sample = JSON.parse(event.data)
+      }
+
+      this.socket.onclose = (event) => {
+        console.log("WebSocket disconnected for stats")
+        this.isConnected = false
+        if (!this.destroyed) {
+          setTimeout(() => this.connectWebSocket(), 3000)
+        }
+      }
+
+      this.socket.onerror = (error) => {
+        console.error("WebSocket error:", error)
+      }
+    },
+
+    formatBytes(bytes) {
+      const units = ["B", "KiB", "MiB", "GiB", "TiB"]
+      let value = bytes
+      let unit = 0
+      while (value >= 1024 && unit < units.length - 1) {
+        value /= 1024
+        unit++
+      }
+      return `${value.toFixed(unit === 0 ? 0 : 1)} ${units[unit]}`
+    },
+
+    formatPercent(value) {
+      return `${value.toFixed(2)}%`
+    },
+
+    barWidth(percent) {
+      return `${Math.min(Math.max(percent, 0), 100)}%`
+    },
+
+    // Cleanup when component is destroyed
+    destroy() {
+      this.destroyed = true
+      clearInterval(this.historyTimer)
+      if (this.socket) {
+        this.socket.close()
+      }
+    },
+  }
+}
@@ -15,12 +15,23 @@
   You should have received a copy of the GNU Affero General Public License
   along with this program. If not, see <https://www.gnu.org/licenses/>.
 */
-export default (containerId) => {
+export default (containerId, query, sessionId) => {
+  const options = new URLSearchParams(query)
+
   return {
     terminal: null,
     socket: null,
     isConnected: false,
+    finished: false, // The process ended or could not be started
+    sessionId: sessionId, // Session to attach to, a new one is started when empty
     containerId: containerId,
+
+    // Exec options, see terminal.ExecOptions
+    command: options.get("cmd") || "", // Empty to use the first shell found
+    user: options.get("user") || "",
+    workdir: options.get("workdir") || "",
+    env: options.getAll("env").join("\n"), // One KEY=VALUE per line
+
     isFullScreenMode: false,
     fitAddon: null,
     fontSize: 12,
@@ -39,7 +50,7 @@ export default (containerId) => {
     },
 
     handleVisibilityChange() {
-      if (!document.hidden && !this.isConnected) {
+      if (!document.hidden && !this.isConnected && !this.finished) {
         this.connectWebSocket()
       }
     },
@@ -72,11 +83,12 @@ export default (containerId) => {
       this.terminal.open(this.$refs.terminalElement)
       this.fitAddon.fit()
 
-      // Send terminal input to WebSocket
+      // Send terminal input and size changes to WebSocket
       this.terminal.onData((data) => {
-        if (this.isConnected && this.socket.readyState === WebSocket.OPEN) {
-          this.socket.send(data)
-        }
+        this.send({ type: "data", data: data })
+      })
+      this.terminal.onResize(({ cols, rows }) => {
+        this.send({ type: "resize", cols: cols, rows: rows })
       })
 
       // Start connection
@@ -99,6 +111,13 @@ export default (containerId) => {
       }
     },
 
+    // send writes a control message, see terminal.Message
+    send(message) {
+      if (this.isConnected && this.socket.readyState === WebSocket.OPEN) {
+        this.socket.send(JSON.stringify(message))
+      }
+    },
+
     connectWebSocket() {
       const protocol = window.location.protocol === "https:" ? "wss:" : "ws:"
 
@@ -106,25 +125,63 @@ export default (containerId) => {
         ? window.location.host.replace("8082", "8300")
         : window.location.host
 
-      const wsUrl = `${protocol}//${locationHost}/terminal/stream/${this.containerId}`
+      // The shell starts at the size of the terminal, later changes are sent
+      // as resize messages
+      // Reconnecting attaches to the same session, which replays what was
+      // missed, instead of starting a new one
+      const params = new URLSearchParams(
+        this.sessionId ? { session: this.sessionId } : this.execQuery(),
+      )
+      params.set("cols", this.terminal.cols)
+      params.set("rows", this.terminal.rows)
+      const wsUrl = `${protocol}//${locationHost}/terminal/stream/${this.containerId}?${params.toString()}`
 
       this.socket = new WebSocket(wsUrl)
+      this.socket.binaryType = "arraybuffer"
 
       this.socket.onopen = (event) => {
         console.log("WebSocket connected")
         this.isConnected = true
-        this.terminal.writeln(
-          "\x1b[32mConnected to container terminal...\x1b[0m\r\n",
-        )
+        this.send({
+          type: "resize",
+          cols: this.terminal.cols,
+          rows: this.terminal.rows,
+        })
       }
 
+      // Output arrives as binary frames, control messages as text
       this.socket.onmessage = (event) => {
-        this.terminal.write(event.data)
+        if (typeof event.data !== "string") {
+          this.terminal.write(new Uint8Array(event.data))
+          return
+        }
+
+        const message = JSON.parse(event.data)
+        if (message.type === "session") {
+          // The scrollback of the session is replayed next
+          this.sessionId = message.session
+          this.terminal.reset()
+          this.terminal.writeln(
+            `\x1b[32mAttached to terminal session ${message.session}...\x1b[0m\r\n`,
+          )
+        }
       }
 
       this.socket.onclose = (event) => {
         console.log("WebSocket disconnected")
         this.isConnected = false
+
+        // The server closes normally when the process exits, and with a
+        // policy violation when it cannot be started
+        if (event.code === 1000 || event.code === 1008) {
+          this.finished = true
+          this.sessionId = ""
+          this.terminal.writeln(
+            `\r\n\x1b[33m${event.reason || "Session ended"}. Press New session to start again.\x1b[0m`,
+          )
+          return
+        }
+
         this.terminal.writeln(
           "\r\n\x1b[31mConnection lost. Attempting to reconnect...\x1b[0m",
         )
@@ -139,9 +196,49 @@ export default (containerId) => {
       }
     },
 
+    // execQuery encodes the exec options for the socket
+    execQuery() {
+      const params = new URLSearchParams()
+      for (const [name, value] of [
+        ["cmd", this.command],
+        ["user", this.user],
+        ["workdir", this.workdir],
+      ]) {
+        if (value.trim()) {
+          params.set(name, value.trim())
+        }
+      }
+      for (const variable of this.env.split(/\r?\n/)) {
+        if (variable.trim()) {
+          params.append("env", variable)
+        }
+      }
+      return params.toString()
+    },
+
+    // applyOptions starts a new session with the options from the form, the
+    // current one keeps running detached
+    applyOptions() {
+      this.attach("")
+    },
+
+    // attach switches to a running session, or a new one when id is empty
+    attach(id) {
+      if (this.socket) {
+        this.socket.onclose = null
+        this.socket.close()
+      }
+      this.isConnected = false
+      this.finished = false
+      this.sessionId = id
+      this.terminal.reset()
+      this.connectWebSocket()
+    },
+
     // Cleanup when component is destroyed
     destroy() {
       if (this.socket) {
+        this.socket.onclose = null
         this.socket.close()
       }
     },
@@ -25,16 +25,25 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/dwui/cmd/alerts"
 	"github.com/dwui/cmd/auth"
 	"github.com/dwui/cmd/containers"
 	"github.com/dwui/cmd/database"
+	"github.com/dwui/cmd/diff"
+	"github.com/dwui/cmd/docker"
+	"github.com/dwui/cmd/events"
+	"github.com/dwui/cmd/files"
+	"github.com/dwui/cmd/forward"
 	"github.com/dwui/cmd/home"
 	"github.com/dwui/cmd/inspect"
 	"github.com/dwui/cmd/logs"
+	"github.com/dwui/cmd/processes"
+	"github.com/dwui/cmd/stats"
 	"github.com/dwui/cmd/terminal"
 )
 
@@ -55,9 +64,24 @@ func main() {
 	var password string
 	var port string
 	var passwordFile string
+	var statsInterval time.Duration
+	var archiveLabel string
+	var archiveRetention time.Duration
+	var forwardConfig forward.Config
+	var terminalIdleTimeout time.Duration
+	var terminalRecordings string
 	flag.StringVar(&password, "password", "", "Password for authentication (if not provided, a random one will be generated)")
 	flag.StringVar(&port, "port", "8300", "Port to run the server on")
 	flag.StringVar(&passwordFile, "password-file", "", "File to store the generated password")
+	flag.DurationVar(&statsInterval, "stats-interval", 15*time.Second, "How often container stats are sampled for the history charts (0 disables sampling)")
+	flag.StringVar(&archiveLabel, "log-archive-label", "dwui.archive=true", "Label (key or key=value) of the containers whose logs are archived")
+	flag.DurationVar(&archiveRetention, "log-archive-retention", 7*24*time.Hour, "How long archived log lines are kept (0 disables the log archive)")
+	flag.StringVar(&forwardConfig.Syslog, "forward-syslog", "", "Syslog server receiving container logs, as udp://host:port or tcp://host:port")
+	flag.StringVar(&forwardConfig.Loki, "forward-loki", "", "Loki compatible push URL receiving container logs, e.g. http://loki:3100/loki/api/v1/push")
+	flag.StringVar(&forwardConfig.Label, "forward-label", "", "Label (key or key=value) of the containers whose logs are forwarded (empty forwards all)")
+	flag.IntVar(&forwardConfig.Buffer, "forward-buffer", 100000, "How many log lines per destination are buffered on disk while it is unreachable")
+	flag.DurationVar(&terminalIdleTimeout, "terminal-idle-timeout", 30*time.Minute, "How long a terminal session keeps running with no browser attached (0 keeps it until it exits)")
+	flag.StringVar(&terminalRecordings, "terminal-recordings", "", "Directory where every terminal session is recorded as an asciicast file (empty disables recording)")
 	flag.Parse()
 
 	// Set up authentication
@@ -83,6 +107,34 @@ func main() {
 	database.Init()
 	auth.SetPassword(password)
 
+	docker.Init()
+	containers.StartCache()
+	if archiveRetention > 0 && archiveLabel != "" {
+		logs.StartArchiver(archiveLabel, archiveRetention)
+	}
+	alerts.Start()
+	if forwardConfig.Enabled() {
+		if err := forward.Start(forwardConfig); err != nil {
+			fmt.Printf("Error starting log forwarding: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	events.Start()
+
+	if terminalRecordings != "" {
+		if err := terminal.StartRecording(terminalRecordings); err != nil {
+			fmt.Printf("Error starting terminal recording: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if terminalIdleTimeout > 0 {
+		terminal.StartReaper(terminalIdleTimeout)
+	}
+
+	if statsInterval > 0 {
+		stats.StartSampler(statsInterval)
+	}
+
 	// Set up graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -116,11 +168,38 @@ func main() {
 
 		r.Get("/", home.Show(templateFiles))
 		r.Get("/containers", containers.Index(templateFiles))
+		r.Get("/containers/row/{containerID}", containers.Row(templateFiles))
+		r.Post("/containers/bulk/{action}", containers.Bulk(templateFiles))
+		r.Post("/containers/{containerID}/{action}", containers.Action(templateFiles))
+		r.Get("/logs/merged", logs.Merged(templateFiles))
+		r.Get("/logs/archive", logs.Archive(templateFiles))
 		r.Get("/logs/{containerID}", logs.Show(templateFiles))
+		r.Get("/logs/stream", logs.Socket)
 		r.Get("/logs/stream/{containerID}", logs.Socket)
+		r.Get("/logs/download/{containerID}", logs.Download)
 		r.Get("/terminal/{containerID}", terminal.Show(templateFiles))
 		r.Get("/terminal/stream/{containerID}", terminal.Socket)
+		r.Get("/terminal/{containerID}/sessions", terminal.SessionList(templateFiles))
+		r.Get("/terminal/recordings", terminal.Recordings(templateFiles))
+		r.Get("/terminal/recordings/{recordingID}", terminal.Playback(templateFiles))
+		r.Get("/terminal/recordings/{recordingID}/cast", terminal.Cast)
 		r.Get("/inspect/{containerID}", inspect.Show(templateFiles))
+		r.Get("/stats/{containerID}", stats.Show(templateFiles))
+		r.Get("/stats/stream/{containerID}", stats.Socket)
+		r.Get("/stats/history/{containerID}", stats.History)
+		r.Get("/processes/{containerID}", processes.Show(templateFiles))
+		r.Get("/processes/table/{containerID}", processes.Table(templateFiles))
+		r.Post("/processes/signal/{containerID}", processes.SendSignal(templateFiles))
+		r.Get("/files/{containerID}", files.Show(templateFiles))
+		r.Get("/files/preview/{containerID}", files.Preview(templateFiles))
+		r.Get("/files/download/{containerID}", files.Download)
+		r.Post("/files/upload/{containerID}", files.Upload(templateFiles))
+		r.Get("/diff/{containerID}", diff.Show(templateFiles))
+		r.Get("/events", events.Index(templateFiles))
+		r.Get("/events/stream", events.Socket)
+		r.Get("/alerts", alerts.Index(templateFiles))
+		r.Post("/alerts/rules", alerts.Create(templateFiles))
+		r.Post("/alerts/rules/{ruleID}/{action}", alerts.Update(templateFiles))
 	})
 
 	fmt.Printf("Starting server on :%s\n", port)